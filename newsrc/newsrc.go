@@ -0,0 +1,271 @@
+// Package newsrc reads and writes .newsrc files, which record the newsgroups a user subscribes to and the articles read
+// in each of them. Every group takes a line made of its name, ':' if subscribed or '!' if not, and the read article
+// numbers in the compact range syntax of nntp.ArticleSet:
+//
+//	comp.lang.go: 1-1520,1523
+//	alt.binaries.test! 1-9000
+package newsrc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/nntp.v0"
+)
+
+var ErrorInvalidLine = errors.New("invalid .newsrc line")
+
+// A newsgroup of a .newsrc file.
+type Group struct {
+	Name       string
+	Subscribed bool
+
+	// The read article numbers, never nil for groups returned by a Newsrc.
+	Read *nntp.ArticleSet
+}
+
+// The content of a .newsrc file. Lines that are not group lines, such as the "options" line of some newsreaders, are
+// kept as is so that writing the file back preserves them. A Newsrc is not safe for concurrent use.
+type Newsrc struct {
+	lines  []line
+	groups map[string]*Group
+}
+
+// A line of the file, either a group or any other line kept verbatim.
+type line struct {
+	group *Group
+	raw   string
+}
+
+// Returns an empty Newsrc.
+func New() *Newsrc {
+	return &Newsrc{groups: map[string]*Group{}}
+}
+
+// Parses a .newsrc file. A group appearing twice keeps its first line, with the read articles of both lines.
+func Parse(r io.Reader) (rc *Newsrc, err error) {
+	rc = New()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		i := strings.IndexAny(text, ":!")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "options") || i < 0 {
+			rc.lines = append(rc.lines, line{raw: text})
+			continue
+		}
+		name := text[:i]
+		if name == "" || strings.ContainsAny(name, " \t") {
+			err = fmt.Errorf("[newsrc.Parse] line %d %#v: %w", n, text, ErrorInvalidLine)
+			return
+		}
+		var read *nntp.ArticleSet
+		if read, err = nntp.ParseArticleSet(text[i+1:]); err != nil {
+			err = fmt.Errorf("[newsrc.Parse] line %d %#v: %w", n, text, ErrorInvalidLine)
+			return
+		}
+		if group, ok := rc.groups[name]; ok {
+			group.Read = group.Read.Union(read)
+			continue
+		}
+		rc.add(&Group{Name: name, Subscribed: text[i] == ':', Read: read})
+	}
+	if err = scanner.Err(); err != nil {
+		err = fmt.Errorf("[newsrc.Parse] failed to read .newsrc: %w", err)
+	}
+	return
+}
+
+// Reads the .newsrc file at path.
+func Load(path string) (rc *Newsrc, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		err = fmt.Errorf("[newsrc.Load] %w", err)
+		return
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Writes the .newsrc file, groups and other lines in the order they were parsed or added.
+func (rc *Newsrc) WriteTo(w io.Writer) (n int64, err error) {
+	bw := bufio.NewWriter(w)
+	for _, l := range rc.lines {
+		var written int
+		if l.group == nil {
+			written, err = fmt.Fprintln(bw, l.raw)
+		} else {
+			mark := "!"
+			if l.group.Subscribed {
+				mark = ":"
+			}
+			if read := l.group.Read.String(); read != "" {
+				written, err = fmt.Fprintf(bw, "%s%s %s\n", l.group.Name, mark, read)
+			} else {
+				written, err = fmt.Fprintf(bw, "%s%s\n", l.group.Name, mark)
+			}
+		}
+		n += int64(written)
+		if err != nil {
+			return
+		}
+	}
+	err = bw.Flush()
+	return
+}
+
+// Writes the .newsrc file at path atomically: the content goes to a temporary file in the same directory, which then
+// replaces the file, so that readers and crashes never see a partly written file. The permissions of the replaced
+// file are kept, new files are only readable by their owner.
+func (rc *Newsrc) Save(path string) (err error) {
+	mode := os.FileMode(0600)
+	if info, e := os.Stat(path); e == nil {
+		mode = info.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		err = fmt.Errorf("[newsrc.Save] failed to create temporary file: %w", err)
+		return
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err = rc.WriteTo(f); err != nil {
+		err = fmt.Errorf("[newsrc.Save] failed to write %s: %w", f.Name(), err)
+		return
+	}
+	if err = f.Chmod(mode); err != nil {
+		err = fmt.Errorf("[newsrc.Save] failed to set permissions of %s: %w", f.Name(), err)
+		return
+	}
+	if err = f.Sync(); err != nil {
+		err = fmt.Errorf("[newsrc.Save] failed to sync %s: %w", f.Name(), err)
+		return
+	}
+	if err = f.Close(); err != nil {
+		err = fmt.Errorf("[newsrc.Save] failed to close %s: %w", f.Name(), err)
+		return
+	}
+	if err = os.Rename(f.Name(), path); err != nil {
+		err = fmt.Errorf("[newsrc.Save] failed to replace %s: %w", path, err)
+	}
+	return
+}
+
+// Returns the groups in file order.
+func (rc *Newsrc) Groups() (groups []*Group) {
+	for _, l := range rc.lines {
+		if l.group != nil {
+			groups = append(groups, l.group)
+		}
+	}
+	return
+}
+
+// Returns the group with the given name, or nil if the file does not list it.
+func (rc *Newsrc) Group(name string) *Group {
+	return rc.groups[name]
+}
+
+// Subscribes to the group, adding it at the end of the file if it is not listed yet.
+func (rc *Newsrc) Subscribe(name string) *Group {
+	group := rc.group(name)
+	group.Subscribed = true
+	return group
+}
+
+// Unsubscribes from the group, keeping its read articles. Groups not listed are added as unsubscribed.
+func (rc *Newsrc) Unsubscribe(name string) *Group {
+	group := rc.group(name)
+	group.Subscribed = false
+	return group
+}
+
+// Removes the group from the file.
+func (rc *Newsrc) Remove(name string) {
+	if rc.groups[name] == nil {
+		return
+	}
+	delete(rc.groups, name)
+	for i, l := range rc.lines {
+		if l.group != nil && l.group.Name == name {
+			rc.lines = append(rc.lines[:i], rc.lines[i+1:]...)
+			return
+		}
+	}
+}
+
+// Marks the articles of the group as read. Groups not listed are added as subscribed.
+func (rc *Newsrc) MarkRead(name string, articleNumbers ...int64) {
+	group := rc.group(name)
+	for _, n := range articleNumbers {
+		group.Read.Add(n)
+	}
+}
+
+// Marks the articles from first to last inclusive of the group as read. Groups not listed are added as subscribed.
+func (rc *Newsrc) MarkRangeRead(name string, first, last int64) {
+	rc.group(name).Read.AddRange(first, last)
+}
+
+// Marks the articles of the group as unread again.
+func (rc *Newsrc) MarkUnread(name string, articleNumbers ...int64) {
+	group := rc.groups[name]
+	if group == nil {
+		return
+	}
+	unread := &nntp.ArticleSet{}
+	for _, n := range articleNumbers {
+		unread.Add(n)
+	}
+	group.Read = group.Read.Difference(unread)
+}
+
+// Marks all the articles of the group up to its last one as read, the group being given as returned by CmdGroup or
+// CmdListGroup. Groups not listed are added as subscribed.
+func (rc *Newsrc) CatchUp(stat *nntp.GroupStat) {
+	group := rc.group(stat.Group)
+	group.Read = &nntp.ArticleSet{}
+	group.Read.AddRange(1, stat.Last)
+}
+
+// Returns the articles of the group not read yet, within the range the server reports for it, ready to be fetched
+// with nntp.WithArticleSet:
+//
+//	conn.CmdOver(nntp.WithArticleSet(rc.Unread(stat)))
+//
+// Every article of the group is unread if the file does not list it.
+func (rc *Newsrc) Unread(stat *nntp.GroupStat) (unread *nntp.ArticleSet) {
+	unread = &nntp.ArticleSet{}
+	if stat.Count == 0 {
+		return
+	}
+	unread.AddRange(stat.First, stat.Last)
+	if group := rc.groups[stat.Group]; group != nil {
+		unread = unread.Difference(group.Read)
+	}
+	return
+}
+
+// Returns the group with the given name, adding it as subscribed if it is not listed.
+func (rc *Newsrc) group(name string) *Group {
+	group := rc.groups[name]
+	if group == nil {
+		group = &Group{Name: name, Subscribed: true, Read: &nntp.ArticleSet{}}
+		rc.add(group)
+	}
+	return group
+}
+
+func (rc *Newsrc) add(group *Group) {
+	rc.groups[group.Name] = group
+	rc.lines = append(rc.lines, line{group: group})
+}
@@ -9,7 +9,7 @@ import (
 type Article struct {
 	// The article number in the currently selected group on the NNTP server. The article number is set to 0 if no
 	// current group is selected, or the article is used as an argument to posting commands.
-	ArticleNumber int
+	ArticleNumber int64
 
 	// The message-id string that globally identifies the article across all groups and all peered NNTP servers. Can be
 	// empty string if the article is used as an argument to posting commands.
@@ -23,7 +23,9 @@ type Article struct {
 	// decoded, that is, leading double dots are unescaped to single dot, and the final ".\r\n" sequence is dropped.
 	// Clients consuming the article should finish consuming the Body content before issuing any other NNTP command on
 	// the connection object where it gets the article, since the connection manager will automatically drain the
-	// article if another NNTP command is issued.
+	// article if another NNTP command is issued. Commands issued through other views of the connection, see
+	// Conn.WithContext, read what is left of the body in memory instead, for it to be read later on. Body implements
+	// io.Closer to drain it.
 	//
 	// Body is only not nil if the article is returned from ARTICLE or BODY commands, or used as an argument to posting
 	// commands.
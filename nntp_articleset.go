@@ -0,0 +1,224 @@
+package nntp
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A set of article numbers, kept as sorted, disjoint and non-adjacent ranges so that the numbers of a whole group take
+// little memory. Its text form is the compact range syntax used by LISTGROUP clients and .newsrc files, e.g.
+// "1-5,7,9-120000". Article numbers lower than 1 are ignored. The zero value is an empty set ready to use, and a nil
+// *ArticleSet reads as an empty set.
+type ArticleSet struct {
+	ranges []Range
+}
+
+// Parses a comma separated list of article numbers and ranges of article numbers, such as "1-5,7,9-120000". The ranges
+// may overlap and come in any order.
+func ParseArticleSet(s string) (set *ArticleSet, err error) {
+	set = &ArticleSet{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var first, last int64
+		firstField, lastField, isRange := strings.Cut(part, "-")
+		if first, err = parseArticleNumber(firstField); err != nil {
+			err = fmt.Errorf("[nntp.ParseArticleSet] invalid article number in %#v: %w", part, ErrorInvalidParams)
+			return
+		}
+		last = first
+		if isRange {
+			if last, err = parseArticleNumber(lastField); err != nil || last < first {
+				err = fmt.Errorf("[nntp.ParseArticleSet] invalid range %#v: %w", part, ErrorInvalidParams)
+				return
+			}
+		}
+		set.AddRange(first, last)
+	}
+	return
+}
+
+// Formats the set in the compact range syntax, e.g. "1-5,7,9-120000". An empty set formats as an empty string.
+func (s *ArticleSet) String() string {
+	var b strings.Builder
+	for i, r := range s.spans() {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatInt(r.First, 10))
+		if r.Last != r.First {
+			b.WriteByte('-')
+			b.WriteString(strconv.FormatInt(r.Last, 10))
+		}
+	}
+	return b.String()
+}
+
+// Adds an article number to the set.
+func (s *ArticleSet) Add(articleNumber int64) {
+	s.AddRange(articleNumber, articleNumber)
+}
+
+// Adds the article numbers from first to last inclusive to the set. Adding numbers in ascending order, as LISTGROUP
+// returns them, takes amortized constant time.
+func (s *ArticleSet) AddRange(first, last int64) {
+	if first < 1 {
+		first = 1
+	}
+	if first > last {
+		return
+	}
+	n := len(s.ranges)
+	if n == 0 || s.ranges[n-1].Last < first-1 {
+		s.ranges = append(s.ranges, Range{first, last})
+		return
+	}
+	if tail := &s.ranges[n-1]; first >= tail.First {
+		// the added range overlaps or touches the last one, e.g. 5 after 1-4
+		if last > tail.Last {
+			tail.Last = last
+		}
+		return
+	}
+	// the ranges from i to j excluded overlap or touch the added one, and merge into it
+	i := sort.Search(n, func(k int) bool { return s.ranges[k].Last >= first-1 })
+	j := sort.Search(n, func(k int) bool { return s.ranges[k].First-1 > last })
+	if i == j {
+		s.ranges = append(s.ranges, Range{})
+		copy(s.ranges[i+1:], s.ranges[i:])
+		s.ranges[i] = Range{first, last}
+		return
+	}
+	if s.ranges[i].First < first {
+		first = s.ranges[i].First
+	}
+	if s.ranges[j-1].Last > last {
+		last = s.ranges[j-1].Last
+	}
+	s.ranges[i] = Range{first, last}
+	s.ranges = append(s.ranges[:i+1], s.ranges[j:]...)
+}
+
+// Reports whether the article number is in the set.
+func (s *ArticleSet) Contains(articleNumber int64) bool {
+	ranges := s.spans()
+	i := sort.Search(len(ranges), func(k int) bool { return ranges[k].Last >= articleNumber })
+	return i < len(ranges) && ranges[i].First <= articleNumber
+}
+
+// Returns the count of article numbers in the set.
+func (s *ArticleSet) Len() (n int64) {
+	for _, r := range s.spans() {
+		n += r.Last - r.First + 1
+	}
+	return
+}
+
+// Reports whether the set holds no article number.
+func (s *ArticleSet) IsEmpty() bool {
+	return len(s.spans()) == 0
+}
+
+// Returns the ranges making up the set, in ascending order. Each range is closed, Last being the last article number
+// in it.
+func (s *ArticleSet) Ranges() []Range {
+	return append([]Range{}, s.spans()...)
+}
+
+// Calls f with each article number of the set in ascending order, until f returns false.
+func (s *ArticleSet) Each(f func(articleNumber int64) bool) {
+	for _, r := range s.spans() {
+		for n := r.First; ; n++ {
+			if !f(n) {
+				return
+			}
+			if n == r.Last {
+				break
+			}
+		}
+	}
+}
+
+// Returns the article numbers in either set.
+func (s *ArticleSet) Union(other *ArticleSet) (union *ArticleSet) {
+	union = &ArticleSet{}
+	a, b := s.spans(), other.spans()
+	for len(a) > 0 || len(b) > 0 {
+		var r Range
+		if len(b) == 0 || len(a) > 0 && a[0].First <= b[0].First {
+			r, a = a[0], a[1:]
+		} else {
+			r, b = b[0], b[1:]
+		}
+		if n := len(union.ranges); n > 0 && union.ranges[n-1].Last >= r.First-1 {
+			if r.Last > union.ranges[n-1].Last {
+				union.ranges[n-1].Last = r.Last
+			}
+			continue
+		}
+		union.ranges = append(union.ranges, r)
+	}
+	return
+}
+
+// Returns the article numbers in both sets.
+func (s *ArticleSet) Intersect(other *ArticleSet) (intersection *ArticleSet) {
+	intersection = &ArticleSet{}
+	a, b := s.spans(), other.spans()
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		first, last := a[i].First, a[i].Last
+		if b[j].First > first {
+			first = b[j].First
+		}
+		if b[j].Last < last {
+			last = b[j].Last
+		}
+		if first <= last {
+			intersection.ranges = append(intersection.ranges, Range{first, last})
+		}
+		if a[i].Last < b[j].Last {
+			i++
+		} else {
+			j++
+		}
+	}
+	return
+}
+
+// Returns the article numbers in the set but not in the other one.
+func (s *ArticleSet) Difference(other *ArticleSet) (difference *ArticleSet) {
+	difference = &ArticleSet{}
+	b := other.spans()
+	j := 0
+	for _, r := range s.spans() {
+		for j < len(b) && b[j].Last < r.First {
+			j++
+		}
+		first, covered := r.First, false
+		for k := j; k < len(b) && b[k].First <= r.Last; k++ {
+			if b[k].First > first {
+				difference.ranges = append(difference.ranges, Range{first, b[k].First - 1})
+			}
+			if b[k].Last >= r.Last {
+				covered = true
+				break
+			}
+			first = b[k].Last + 1
+		}
+		if !covered {
+			difference.ranges = append(difference.ranges, Range{first, r.Last})
+		}
+	}
+	return
+}
+
+func (s *ArticleSet) spans() []Range {
+	if s == nil {
+		return nil
+	}
+	return s.ranges
+}
@@ -0,0 +1,156 @@
+package nntp
+
+import (
+	"strings"
+)
+
+// The capabilities advertised by the server in response to the CAPABILITIES command, see RFC 3977 section 5.2.
+type Capabilities struct {
+	// The protocol versions supported by the server, "2" for RFC 3977.
+	Version []string
+
+	// The server implementation, meant for information only.
+	Implementation string
+
+	// READER: the reader commands are available, possibly after MODE READER when ModeReader is set.
+	Reader bool
+
+	// MODE-READER: the server is mode-switching and MODE READER must be issued before reader commands.
+	ModeReader bool
+
+	// IHAVE: the IHAVE transit command is available.
+	IHave bool
+
+	// POST: the POST command is available.
+	Post bool
+
+	// NEWNEWS: the NEWNEWS command is available.
+	NewNews bool
+
+	// HDR: the HDR and LIST HEADERS commands are available.
+	Hdr bool
+
+	// OVER: the OVER and LIST OVERVIEW.FMT commands are available.
+	Over bool
+
+	// OVER MSGID: the OVER command also accepts a message-id.
+	OverMessageID bool
+
+	// STARTTLS: the connection can be upgraded to TLS, see Conn.StartTLS.
+	StartTLS bool
+
+	// STREAMING: the MODE STREAM, CHECK and TAKETHIS commands are available.
+	Streaming bool
+
+	// The keywords of the supported LIST variants, e.g. "ACTIVE" or "OVERVIEW.FMT".
+	List []string
+
+	// The SASL mechanisms usable with AUTHINFO SASL.
+	SASL []string
+
+	// The compression algorithms usable with COMPRESS.
+	Compress []string
+
+	// Every capability line, indexed by its upper case label.
+	labels map[string][]string
+}
+
+// Parses the lines of a CAPABILITIES response. Capability labels are case-insensitive, unknown ones are only available
+// through Has and Args.
+func ParseCapabilities(lines []string) *Capabilities {
+	c := &Capabilities{labels: make(map[string][]string, len(lines))}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		label, args := strings.ToUpper(fields[0]), fields[1:]
+		c.labels[label] = args
+		switch label {
+		case "VERSION":
+			c.Version = args
+		case "IMPLEMENTATION":
+			c.Implementation = strings.TrimSpace(line[len(fields[0]):])
+		case "READER":
+			c.Reader = true
+		case "MODE-READER":
+			c.ModeReader = true
+		case "IHAVE":
+			c.IHave = true
+		case "POST":
+			c.Post = true
+		case "NEWNEWS":
+			c.NewNews = true
+		case "HDR":
+			c.Hdr = true
+		case "OVER":
+			c.Over = true
+			c.OverMessageID = hasArg(args, "MSGID")
+		case "STARTTLS":
+			c.StartTLS = true
+		case "STREAMING":
+			c.Streaming = true
+		case "LIST":
+			c.List = args
+		case "SASL":
+			c.SASL = args
+		case "COMPRESS":
+			c.Compress = args
+		}
+	}
+	return c
+}
+
+// Reports whether the capability label is advertised with all the given arguments, e.g. Has("OVER", "MSGID") or
+// Has("LIST", "ACTIVE"). Labels and arguments are compared case-insensitively.
+func (c *Capabilities) Has(label string, args ...string) bool {
+	advertised, ok := c.labels[strings.ToUpper(label)]
+	if !ok {
+		return false
+	}
+	for _, arg := range args {
+		if !hasArg(advertised, arg) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the arguments of the capability label and whether it is advertised.
+func (c *Capabilities) Args(label string) (args []string, ok bool) {
+	args, ok = c.labels[strings.ToUpper(label)]
+	return
+}
+
+func hasArg(args []string, arg string) bool {
+	for _, a := range args {
+		if strings.EqualFold(a, arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the server capabilities, issuing a CAPABILITIES command unless they are already cached on the connection. As
+// required by RFC 3977, the cache is discarded whenever the capabilities may change: after STARTTLS, COMPRESS,
+// successful authentication and MODE READER.
+func (conn *Conn) Capabilities() (capabilities *Capabilities, err error) {
+	conn.mu.Lock()
+	capabilities = conn.capabilities
+	conn.mu.Unlock()
+	if capabilities != nil {
+		return
+	}
+	return conn.CmdCapabilities()
+}
+
+// Caches the capabilities, or discards the cache when nil. The cached overview format is discarded along with them,
+// since it may change with the session as well.
+func (conn *Conn) setCapabilities(capabilities *Capabilities) {
+	conn.mu.Lock()
+	conn.capabilities = capabilities
+	if capabilities == nil {
+		conn.overviewFormat = nil
+	}
+	conn.mu.Unlock()
+}
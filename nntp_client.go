@@ -0,0 +1,141 @@
+package nntp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"gopkg.in/option.v0"
+)
+
+// A Provider is one of the servers, or one of the accounts on a server, a Client fetches articles from.
+type Provider struct {
+	// Name identifies the provider in errors, e.g. "primary" or "block account".
+	Name string
+
+	// Priority orders the providers, lower values are tried first. Providers sharing the same priority are tried in the
+	// order they are given to NewClient.
+	Priority int
+
+	// Pool holds the connections to the provider, along with its dialer and credentials.
+	Pool *Pool
+}
+
+// A Client fetches articles by message-id from several providers, falling back to the next provider whenever one does
+// not have the article or fails. It is typically used with a main account and one or more backup (block) accounts.
+// A Client is safe for concurrent use as long as the pools of its providers are.
+type Client struct {
+	providers []*Provider
+}
+
+// Returns a Client trying the providers in priority order.
+func NewClient(providers ...*Provider) *Client {
+	sorted := append([]*Provider{}, providers...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+	return &Client{sorted}
+}
+
+// Returns the providers in the order they are tried.
+func (c *Client) Providers() []*Provider {
+	return append([]*Provider{}, c.providers...)
+}
+
+// Closes the pools of all the providers.
+func (c *Client) Close() (err error) {
+	for _, p := range c.providers {
+		if e := p.Pool.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+// Fetches an article with the ARTICLE command from the first provider that has it, and returns that provider along
+// with the article. The article must be selected with ArticleMessageID, since article numbers differ between servers.
+// The body is read in full before the connection is returned to its pool, so that a transfer failing midway also
+// falls back to the next provider.
+func (c *Client) CmdArticle(ctx context.Context, options ...ArticleOption) (article *Article, provider *Provider, err error) {
+	return c.do(ctx, "CmdArticle", options, (*Conn).CmdArticle)
+}
+
+// Same as CmdArticle with the HEAD command.
+func (c *Client) CmdHead(ctx context.Context, options ...ArticleOption) (article *Article, provider *Provider, err error) {
+	return c.do(ctx, "CmdHead", options, (*Conn).CmdHead)
+}
+
+// Same as CmdArticle with the BODY command.
+func (c *Client) CmdBody(ctx context.Context, options ...ArticleOption) (article *Article, provider *Provider, err error) {
+	return c.do(ctx, "CmdBody", options, (*Conn).CmdBody)
+}
+
+// Same as CmdArticle with the STAT command, returning the first provider that has the article.
+func (c *Client) CmdStat(ctx context.Context, options ...ArticleOption) (article *Article, provider *Provider, err error) {
+	return c.do(ctx, "CmdStat", options, (*Conn).CmdStat)
+}
+
+func (c *Client) do(ctx context.Context, name string, options []ArticleOption,
+	cmd func(*Conn, ...ArticleOption) (*Article, error)) (article *Article, provider *Provider, err error) {
+	opts := option.New(options)
+	if opts.messageID == "" {
+		err = fmt.Errorf("[nntp.Client.%s] articles can only be requested by message-id: %w", name, ErrorInvalidParams)
+		return
+	}
+	if len(c.providers) == 0 {
+		err = fmt.Errorf("[nntp.Client.%s] no providers: %w", name, ErrorInvalidParams)
+		return
+	}
+	var missing error
+	for _, p := range c.providers {
+		article, err = fetch(ctx, p, options, cmd)
+		if err == nil {
+			provider = p
+			return
+		}
+		if ctx.Err() != nil || errors.Is(err, ErrorInvalidParams) || errors.Is(err, ErrorInvalidMessageID) {
+			err = fmt.Errorf("[nntp.Client.%s] provider %s: %w", name, p.Name, err)
+			return
+		}
+		if missing == nil && isMissingArticle(err) {
+			missing = fmt.Errorf("provider %s: %w", p.Name, err)
+		}
+	}
+	// a provider not having the article is more telling than the failure of the providers tried after it
+	if missing != nil {
+		err = missing
+	}
+	err = fmt.Errorf("[nntp.Client.%s] %s not available from any of %d providers: %w", name, opts.messageID.Full(),
+		len(c.providers), err)
+	article = nil
+	return
+}
+
+func fetch(ctx context.Context, p *Provider, options []ArticleOption,
+	cmd func(*Conn, ...ArticleOption) (*Article, error)) (article *Article, err error) {
+	conn, err := p.Pool.Get(ctx)
+	if err != nil {
+		return
+	}
+	defer p.Pool.Put(conn)
+	if article, err = cmd(conn.WithContext(ctx), options...); err != nil {
+		return
+	}
+	if article.Body != nil {
+		var body []byte
+		if body, err = io.ReadAll(article.Body); err != nil {
+			article = nil
+			return
+		}
+		article.Body = bytes.NewReader(body)
+	}
+	return
+}
+
+// Reports whether err is a 430 or 423 response.
+func isMissingArticle(err error) bool {
+	return errors.Is(err, ResponseCodeNoSuchArticleId) || errors.Is(err, ResponseCodeNoSuchArticleNumber)
+}
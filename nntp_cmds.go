@@ -3,6 +3,8 @@ package nntp
 import (
 	"bufio"
 	"bytes"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"strconv"
@@ -14,16 +16,20 @@ import (
 	"gopkg.in/textproto.v0"
 )
 
-/**
- * Returns servers capabilities
- *
- * @return mixed (array) list of capabilities
- */
-func (conn *Conn) CmdCapabilities() (capabilities []string, err error) {
+// Fetches the server capabilities and caches them on the connection, see Capabilities.
+func (conn *Conn) CmdCapabilities() (capabilities *Capabilities, err error) {
+	cmd, err := conn.beginRequest("CmdCapabilities")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
 	if err = conn.PrintfLine("CAPABILITIES"); err != nil {
 		err = fmt.Errorf("[nntp.CmdCapabilities] failed to send CAPABILITIES command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdCapabilities] failed to read CAPABILITIES response: %w", err)
@@ -31,10 +37,23 @@ func (conn *Conn) CmdCapabilities() (capabilities []string, err error) {
 	}
 	switch ResponseCode(code) {
 	case ResponseCodeCapabilitiesFollow: // 101
-		if capabilities, err = conn.ReadDotLines(); err != nil {
+		var lines []string
+		if lines, err = conn.ReadDotLines(); err != nil {
 			err = fmt.Errorf("[nntp.CmdCapabilities] failed to read CAPABILITIES response body: %w", err)
 			return
 		}
+		capabilities = ParseCapabilities(lines)
+		conn.setCapabilities(capabilities)
+		conn.updateState(func(state *SessionState) {
+			if state.Mode != SessionModeUnknown {
+				return
+			}
+			if capabilities.ModeReader || !capabilities.Reader && capabilities.IHave {
+				state.Mode = SessionModeTransit
+			} else if capabilities.Reader {
+				state.Mode = SessionModeReader
+			}
+		})
 	default:
 		err = fmt.Errorf("[nntp.CmdCapabilities] unexpected response: %w", &Error{ResponseCode(code), msg})
 	}
@@ -47,6 +66,11 @@ func (conn *Conn) CmdCapabilities() (capabilities []string, err error) {
  * @return true when posting allowed, false when posting disallowed.
  */
 func (conn *Conn) CmdModeReader() (postingAllowed bool, err error) {
+	end, err := conn.begin("CmdModeReader")
+	if err != nil {
+		return
+	}
+	defer end(&err)
 	if err = conn.PrintfLine("MODE READER"); err != nil {
 		err = fmt.Errorf("[nntp.CmdModeReader] failed to send MODE READER command: %w", err)
 		return
@@ -59,10 +83,44 @@ func (conn *Conn) CmdModeReader() (postingAllowed bool, err error) {
 	switch ResponseCode(code) {
 	case ResponseCodeReadyPostingAllowed: // 200
 		postingAllowed = true
+		conn.setCapabilities(nil)
 	case ResponseCodeReadyPostingProhibited: // 201
 		postingAllowed = false
+		conn.setCapabilities(nil)
 	default:
 		err = fmt.Errorf("[nntp.CmdModeReader] unexpected response: %w", &Error{ResponseCode(code), msg})
+		return
+	}
+	conn.updateState(func(state *SessionState) {
+		state.Mode, state.PostingAllowed = SessionModeReader, postingAllowed
+	})
+	return
+}
+
+// Switches a transit connection to the streaming mode of RFC 4644, allowing the CHECK and TAKETHIS commands used by
+// CmdStreamFeed.
+func (conn *Conn) CmdModeStream() (err error) {
+	end, err := conn.begin("CmdModeStream")
+	if err != nil {
+		return
+	}
+	defer end(&err)
+	if err = conn.PrintfLine("MODE STREAM"); err != nil {
+		err = fmt.Errorf("[nntp.CmdModeStream] failed to send MODE STREAM command: %w", err)
+		return
+	}
+	code, msg, err := conn.ReadCodeLine(0)
+	if err != nil {
+		err = fmt.Errorf("[nntp.CmdModeStream] failed to read MODE STREAM response: %w", err)
+		return
+	}
+	switch ResponseCode(code) {
+	case ResponseCodeStreamingPermitted: // 203
+		conn.updateState(func(state *SessionState) {
+			state.Mode = SessionModeStream
+		})
+	default:
+		err = fmt.Errorf("[nntp.CmdModeStream] unexpected response: %w", &Error{ResponseCode(code), msg})
 	}
 	return
 }
@@ -71,10 +129,18 @@ func (conn *Conn) CmdModeReader() (postingAllowed bool, err error) {
  * Disconnect from the NNTP server.
  */
 func (conn *Conn) CmdQuit() (err error) {
+	cmd, err := conn.beginRequest("CmdQuit")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
 	if err = conn.PrintfLine("QUIT"); err != nil {
 		err = fmt.Errorf("[nntp.CmdQuit] failed to send QUIT command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdQuit] failed to read QUIT response: %w", err)
@@ -100,10 +166,19 @@ func (conn *Conn) CmdQuit() (err error) {
  * @return groupinfo on success
  */
 func (conn *Conn) CmdGroup(newsgroup string) (groupinfo *GroupStat, err error) {
+	defer conn.selectingGroup()()
+	cmd, err := conn.beginRequest("CmdGroup")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
 	if err = conn.PrintfLine("GROUP %s", newsgroup); err != nil {
 		err = fmt.Errorf("[nntp.CmdGroup] failed to send GROUP command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdGroup] failed to read GROUP response: %w", err)
@@ -117,6 +192,7 @@ func (conn *Conn) CmdGroup(newsgroup string) (groupinfo *GroupStat, err error) {
 			return
 		}
 		groupinfo = info
+		conn.selectGroup(info)
 	default:
 		err = fmt.Errorf("[nntp.CmdGroup] unexpected response: %w", &Error{ResponseCode(code), msg})
 	}
@@ -130,8 +206,20 @@ func (conn *Conn) CmdGroup(newsgroup string) (groupinfo *GroupStat, err error) {
  * @return optional mixed (array) on success or (object) pear_error on failure
  * @access protected
  */
-func (conn *Conn) CmdListGroup(options ...GroupOption) (groupinfo *GroupStat, articles []int, err error) {
+func (conn *Conn) CmdListGroup(options ...GroupOption) (groupinfo *GroupStat, articles *ArticleSet, err error) {
 	opts := option.New(options)
+	if opts.groupName == "" {
+		if err = conn.requireGroup("CmdListGroup"); err != nil {
+			return
+		}
+	} else {
+		defer conn.selectingGroup()()
+	}
+	cmd, err := conn.beginRequest("CmdListGroup")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
 	if opts.groupName == "" {
 		err = conn.PrintfLine("LISTGROUP")
 	} else if opts.groupRange == nil {
@@ -143,6 +231,9 @@ func (conn *Conn) CmdListGroup(options ...GroupOption) (groupinfo *GroupStat, ar
 		err = fmt.Errorf("[nntp.CmdListGroup] failed to send LISTGROUP command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdListGroup] failed to read LISTGROUP response: %w", err)
@@ -155,34 +246,51 @@ func (conn *Conn) CmdListGroup(options ...GroupOption) (groupinfo *GroupStat, ar
 			err = fmt.Errorf("[nntp.CmdListGroup] failed to parse group info response: %#v: %w", msg, ErrorParsingResponse)
 			return
 		}
-		var lines []string
-		if lines, err = conn.ReadDotLines(); err != nil {
-			err = fmt.Errorf("[nntp.CmdListGroup] failed to read LISTGROUP response body: %w", err)
-			return
-		}
-		ids := make([]int, len(lines))
-		for i, line := range lines {
-			if ids[i], err = strconv.Atoi(line); err != nil {
-				err = fmt.Errorf("[nntp.CmdListGroup] failed to parse article ID: %#v: %w", line, err)
+		set := &ArticleSet{}
+		err = conn.scanLines(func(line string) (more bool, err error) {
+			var articleNumber int64
+			if articleNumber, err = parseArticleNumber(line); err != nil {
+				err = fmt.Errorf("failed to parse article ID: %#v: %w", line, err)
 				return
 			}
+			set.Add(articleNumber)
+			return true, nil
+		})
+		if err != nil {
+			err = fmt.Errorf("[nntp.CmdListGroup] %w", err)
+			return
 		}
-		groupinfo, articles = info, ids
+		groupinfo, articles = info, set
+		conn.selectGroup(info)
 	default:
 		err = fmt.Errorf("[nntp.CmdListGroup] unexpected response: %w", &Error{ResponseCode(code), msg})
 	}
 	return
 }
 
-// If the currently selected newsgroup is valid, the current article * number MUST be set to the previous article in
-// that newsgroup (that * is, the highest existing article number less than the current article * number).  If
-// successful, a response indicating the new current * article number and the message-id of that article MUST be
-// returned. * No article text is sent in response to this command.
-func (conn *Conn) CmdLast(newsgroup string) (err error) {
-	if err = conn.PrintfLine("LAST %s", newsgroup); err != nil {
+// If the currently selected newsgroup is valid, the current article number MUST be set to the previous article in that
+// newsgroup (that is, the highest existing article number less than the current article number).  If successful, a
+// response indicating the new current article number and the message-id of that article MUST be returned. No article
+// text is sent in response to this command.
+//
+// The returned article only carries its number and message-id. An error wrapping ResponseCodeNoPreviousArticle is
+// returned when the current article is the first one of the group.
+func (conn *Conn) CmdLast() (article *Article, err error) {
+	if err = conn.requireGroup("CmdLast"); err != nil {
+		return
+	}
+	cmd, err := conn.beginRequest("CmdLast")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
+	if err = conn.PrintfLine("LAST"); err != nil {
 		err = fmt.Errorf("[nntp.CmdLast] failed to send LAST command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdLast] failed to read LAST response: %w", err)
@@ -190,6 +298,12 @@ func (conn *Conn) CmdLast(newsgroup string) (err error) {
 	}
 	switch ResponseCode(code) {
 	case ResponseCodeArticleSelected: // 223
+		article = new(Article)
+		if _, err = fmt.Sscanf(msg, "%d %s", &article.ArticleNumber, &article.MessageID); err != nil {
+			err = fmt.Errorf("[nntp.CmdLast] failed to parse LAST command status line: %#v: %w", msg, ErrorParsingResponse)
+			return
+		}
+		conn.setArticleNumber(article.ArticleNumber)
 	default:
 		err = fmt.Errorf("[nntp.CmdLast] unexpected response: %w", &Error{ResponseCode(code), msg})
 	}
@@ -200,11 +314,25 @@ func (conn *Conn) CmdLast(newsgroup string) (err error) {
 // newsgroup (that is, the lowest existing article number greater than the current article number).  If successful, a
 // response indicating the new current article number and the message-id of that article MUST be returned. No article
 // text is sent in response to this command.
-func (conn *Conn) CmdNext(newsgroup string) (err error) {
-	if err = conn.PrintfLine("NEXT %s", newsgroup); err != nil {
+//
+// The returned article only carries its number and message-id. An error wrapping ResponseCodeNoNextArticle is returned
+// when the current article is the last one of the group.
+func (conn *Conn) CmdNext() (article *Article, err error) {
+	if err = conn.requireGroup("CmdNext"); err != nil {
+		return
+	}
+	cmd, err := conn.beginRequest("CmdNext")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
+	if err = conn.PrintfLine("NEXT"); err != nil {
 		err = fmt.Errorf("[nntp.CmdNext] failed to send NEXT command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdNext] failed to read NEXT response: %w", err)
@@ -212,6 +340,12 @@ func (conn *Conn) CmdNext(newsgroup string) (err error) {
 	}
 	switch ResponseCode(code) {
 	case ResponseCodeArticleSelected: // 223
+		article = new(Article)
+		if _, err = fmt.Sscanf(msg, "%d %s", &article.ArticleNumber, &article.MessageID); err != nil {
+			err = fmt.Errorf("[nntp.CmdNext] failed to parse NEXT command status line: %#v: %w", msg, ErrorParsingResponse)
+			return
+		}
+		conn.setArticleNumber(article.ArticleNumber)
 	default:
 		err = fmt.Errorf("[nntp.CmdNext] unexpected response: %w", &Error{ResponseCode(code), msg})
 	}
@@ -221,6 +355,16 @@ func (conn *Conn) CmdNext(newsgroup string) (err error) {
 // Selects and presents the entire article.
 func (conn *Conn) CmdArticle(options ...ArticleOption) (article *Article, err error) {
 	opts := option.New(options)
+	if opts.messageID == "" {
+		if err = conn.requireGroup("CmdArticle"); err != nil {
+			return
+		}
+	}
+	cmd, err := conn.beginRequest("CmdArticle")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
 	if opts.messageID != "" {
 		err = conn.PrintfLine("ARTICLE %s", opts.messageID.Full())
 	} else if opts.articleNumber != 0 {
@@ -232,6 +376,9 @@ func (conn *Conn) CmdArticle(options ...ArticleOption) (article *Article, err er
 		err = fmt.Errorf("[nntp.CmdArticle] failed to send ARTICLE command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdArticle] failed to read ARTICLE response: %w", err)
@@ -244,14 +391,17 @@ func (conn *Conn) CmdArticle(options ...ArticleOption) (article *Article, err er
 			err = fmt.Errorf("[nntp.CmdArticle] failed to parse ARTICLE command status line: %#v: %w", msg, ErrorParsingResponse)
 			return
 		}
+		if opts.messageID == "" {
+			conn.setArticleNumber(article.ArticleNumber)
+		}
 		if article.Header, err = conn.ReadMIMEHeader(); err != nil {
 			err = fmt.Errorf("[nntp.CmdArticle] failed to parse MIME header: %#v: %w", msg, ErrorParsingResponse)
 			return
 		}
 		if opts.dotEncodedBody {
-			article.Body = conn.DotReader(textproto.DisableDotDecoding)
+			article.Body = cmd.bodyReader(conn.DotReader(textproto.DisableDotDecoding))
 		} else {
-			article.Body = conn.DotReader()
+			article.Body = cmd.bodyReader(conn.DotReader())
 		}
 	default:
 		err = fmt.Errorf("[nntp.CmdArticle] unexpected response: %w", &Error{ResponseCode(code), msg})
@@ -261,6 +411,16 @@ func (conn *Conn) CmdArticle(options ...ArticleOption) (article *Article, err er
 
 func (conn *Conn) CmdHead(options ...ArticleOption) (article *Article, err error) {
 	opts := option.New(options)
+	if opts.messageID == "" {
+		if err = conn.requireGroup("CmdHead"); err != nil {
+			return
+		}
+	}
+	cmd, err := conn.beginRequest("CmdHead")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
 	if opts.messageID != "" {
 		err = conn.PrintfLine("HEAD %s", opts.messageID.Full())
 	} else {
@@ -270,6 +430,9 @@ func (conn *Conn) CmdHead(options ...ArticleOption) (article *Article, err error
 		err = fmt.Errorf("[nntp.CmdHead] failed to send HEAD command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdHead] failed to read HEAD response: %w", err)
@@ -282,8 +445,11 @@ func (conn *Conn) CmdHead(options ...ArticleOption) (article *Article, err error
 			err = fmt.Errorf("[nntp.CmdHead] failed to parse HEAD response: %#v: %w", msg, ErrorParsingResponse)
 			return
 		}
-		if article.Header, err = conn.ReadMIMEHeader(); err != nil {
-			err = fmt.Errorf("[nntp.CmdArticle] failed to parse MIME header: %#v: %w", msg, ErrorParsingResponse)
+		if opts.messageID == "" {
+			conn.setArticleNumber(article.ArticleNumber)
+		}
+		if article.Header, err = conn.readHeadBlock(); err != nil {
+			err = fmt.Errorf("[nntp.CmdHead] failed to parse MIME header: %#v: %w", msg, ErrorParsingResponse)
 			return
 		}
 	default:
@@ -294,6 +460,16 @@ func (conn *Conn) CmdHead(options ...ArticleOption) (article *Article, err error
 
 func (conn *Conn) CmdBody(options ...ArticleOption) (article *Article, err error) {
 	opts := option.New(options)
+	if opts.messageID == "" {
+		if err = conn.requireGroup("CmdBody"); err != nil {
+			return
+		}
+	}
+	cmd, err := conn.beginRequest("CmdBody")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
 	if opts.messageID != "" {
 		err = conn.PrintfLine("BODY %s", opts.messageID.Full())
 	} else if opts.articleNumber != 0 {
@@ -305,6 +481,9 @@ func (conn *Conn) CmdBody(options ...ArticleOption) (article *Article, err error
 		err = fmt.Errorf("[nntp.CmdBody] failed to send BODY command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdBody] failed to read BODY response: %w", err)
@@ -317,10 +496,13 @@ func (conn *Conn) CmdBody(options ...ArticleOption) (article *Article, err error
 			err = fmt.Errorf("[nntp.CmdBody] failed to parse BODY command status line: %#v: %w", msg, ErrorParsingResponse)
 			return
 		}
+		if opts.messageID == "" {
+			conn.setArticleNumber(article.ArticleNumber)
+		}
 		if opts.dotEncodedBody {
-			article.Body = conn.DotReader(textproto.DisableDotDecoding)
+			article.Body = cmd.bodyReader(conn.DotReader(textproto.DisableDotDecoding))
 		} else {
-			article.Body = conn.DotReader()
+			article.Body = cmd.bodyReader(conn.DotReader())
 		}
 	default:
 		err = fmt.Errorf("[nntp.CmdBody] unexpected response: %w", &Error{ResponseCode(code), msg})
@@ -328,61 +510,18 @@ func (conn *Conn) CmdBody(options ...ArticleOption) (article *Article, err error
 	return
 }
 
-func (conn *Conn) CmdStreamBody(messageIDStream rx.Observable[MessageID]) rx.Observable[*Article] {
-	return rx.Func(func(subscriber rx.Writer[*Article]) (err error) {
-		idWriter, idReader := rx.Pipe[MessageID](subscriber)
-		subscriber.Go(func() (err error) {
-			for {
-				if id, ok := idReader.Read(); ok {
-					if err = conn.PrintfLine("BODY %s", id.Full()); err != nil {
-						err = fmt.Errorf("[nntp.CmdStreamBody] failed to send BODY command: %w", err)
-						return
-					}
-				} else {
-					return
-				}
-			}
-		})
-		subscriber.Go(func() (err error) {
-			var (
-				code    int
-				msg     string
-				article *Article
-				body    []byte
-			)
-			for {
-				if code, msg, err = conn.ReadCodeLine(0); err != nil {
-					err = fmt.Errorf("[nntp.CmdStreamBody] failed to read BODY response: %w", err)
-					return
-				}
-				switch ResponseCode(code) {
-				case ResponseCodeBodyFollows: // 222
-					article = new(Article)
-					if _, err = fmt.Sscanf(msg, "%d %s", &article.ArticleNumber, &article.MessageID); err != nil {
-						err = fmt.Errorf("[nntp.CmdStreamBody] failed to parse BODY command status line: %#v: %w", msg, ErrorParsingResponse)
-						return
-					}
-					if body, err = io.ReadAll(conn.DotReader()); err != nil {
-						err = fmt.Errorf("[nntp.CmdStreamBody] failed to read BODY payload: %w", ErrorParsingResponse)
-						return
-					}
-					article.Body = bytes.NewReader(body)
-					if !subscriber.Write(article) {
-						return
-					}
-				default:
-					err = fmt.Errorf("[nntp.CmdStreamBody] unexpected response: %w", &Error{ResponseCode(code), msg})
-					return
-				}
-			}
-		})
-		messageIDStream.Subscribe(idWriter)
-		return
-	})
-}
-
 func (conn *Conn) CmdStat(options ...ArticleOption) (article *Article, err error) {
 	opts := option.New(options)
+	if opts.messageID == "" {
+		if err = conn.requireGroup("CmdStat"); err != nil {
+			return
+		}
+	}
+	cmd, err := conn.beginRequest("CmdStat")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
 	if opts.messageID != "" {
 		err = conn.PrintfLine("STAT %s", opts.messageID.Full())
 	} else if opts.articleNumber != 0 {
@@ -394,6 +533,9 @@ func (conn *Conn) CmdStat(options ...ArticleOption) (article *Article, err error
 		err = fmt.Errorf("[nntp.CmdStat] failed to send STAT command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdStat] failed to read STAT response: %w", err)
@@ -406,6 +548,9 @@ func (conn *Conn) CmdStat(options ...ArticleOption) (article *Article, err error
 			err = fmt.Errorf("[nntp.CmdStat] failed to parse STAT command status line: %#v: %w", msg, ErrorParsingResponse)
 			return
 		}
+		if opts.messageID == "" {
+			conn.setArticleNumber(article.ArticleNumber)
+		}
 	default:
 		err = fmt.Errorf("[nntp.CmdStat] unexpected response: %w", &Error{ResponseCode(code), msg})
 	}
@@ -413,6 +558,11 @@ func (conn *Conn) CmdStat(options ...ArticleOption) (article *Article, err error
 }
 
 func (conn *Conn) CmdPost(article *Article, options ...ArticleOption) (err error) {
+	end, err := conn.begin("CmdPost")
+	if err != nil {
+		return
+	}
+	defer end(&err)
 	opts := option.New(options)
 	if err = conn.PrintfLine("POST"); err != nil {
 		err = fmt.Errorf("[nntp.CmdPost] failed to send POST command: %w", err)
@@ -474,6 +624,11 @@ func (conn *Conn) CmdPost(article *Article, options ...ArticleOption) (err error
 }
 
 func (conn *Conn) CmdIHave(article *Article) (err error) {
+	end, err := conn.begin("CmdIHave")
+	if err != nil {
+		return
+	}
+	defer end(&err)
 	if err = conn.PrintfLine("IHAVE"); err != nil {
 		err = fmt.Errorf("[nntp.CmdIHave] failed to send IHAVE command: %w", err)
 		return
@@ -530,10 +685,18 @@ func (conn *Conn) CmdIHave(article *Article) (err error) {
 
 // Get the date from the newsserver format of returned date
 func (conn *Conn) CmdDate() (date time.Time, err error) {
+	cmd, err := conn.beginRequest("CmdDate")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
 	if err = conn.PrintfLine("DATE"); err != nil {
 		err = fmt.Errorf("[nntp.CmdDate] failed to send DATE command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdDate] failed to read DATE response: %w", err)
@@ -553,10 +716,18 @@ func (conn *Conn) CmdDate() (date time.Time, err error) {
 
 // Returns the server's help text
 func (conn *Conn) CmdHelp() (help io.Reader, err error) {
+	cmd, err := conn.beginRequest("CmdHelp")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
 	if err = conn.PrintfLine("HELP"); err != nil {
 		err = fmt.Errorf("[nntp.CmdHelp] failed to send HELP command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdHelp] failed to read HELP response: %w", err)
@@ -564,7 +735,7 @@ func (conn *Conn) CmdHelp() (help io.Reader, err error) {
 	}
 	switch ResponseCode(code) {
 	case ResponseCodeHelpFollows: // 100
-		help = conn.DotReader()
+		help = cmd.bodyReader(conn.DotReader())
 	default:
 		err = fmt.Errorf("[nntp.CmdHelp] unexpected response: %w", &Error{ResponseCode(code), msg})
 	}
@@ -573,6 +744,11 @@ func (conn *Conn) CmdHelp() (help io.Reader, err error) {
 
 // Fetches a list of all newsgroups created since a specified date
 func (conn *Conn) CmdNewGroups(date time.Time, useGMT bool) (groups []GroupListItem, err error) {
+	cmd, err := conn.beginRequest("CmdNewGroups")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
 	datestring := date.Format("20060102 150405")
 	if useGMT {
 		err = conn.PrintfLine("NEWGROUPS %s GMT", datestring)
@@ -583,6 +759,9 @@ func (conn *Conn) CmdNewGroups(date time.Time, useGMT bool) (groups []GroupListI
 		err = fmt.Errorf("[nntp.CmdNewGroups] failed to send NEWGROUPS command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdNewGroups] failed to read NEWGROUPS response: %w", err)
@@ -612,6 +791,11 @@ func (conn *Conn) CmdNewGroups(date time.Time, useGMT bool) (groups []GroupListI
 // Fetches a list of message-ids of articles posted or received on the server, in the newsgroups whose names match the
 // wildmat, since the specified date and time.
 func (conn *Conn) CmdNewNews(wildmat string, date time.Time, useGMT bool) (messageIds []string, err error) {
+	cmd, err := conn.beginRequest("CmdNewNews")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
 	if wildmat == "" {
 		err = fmt.Errorf("[nntp.CmdNewNews] empty wildmat: %w", ErrorInvalidParams)
 		return
@@ -626,6 +810,9 @@ func (conn *Conn) CmdNewNews(wildmat string, date time.Time, useGMT bool) (messa
 		err = fmt.Errorf("[nntp.CmdNewNews] failed to send NEWNEWS command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdNewNews] failed to read NEWNEWS response: %w", err)
@@ -645,10 +832,18 @@ func (conn *Conn) CmdNewNews(wildmat string, date time.Time, useGMT bool) (messa
 
 // Fetches a list of all avaible newsgroups.
 func (conn *Conn) CmdList() (groups []GroupListItem, err error) {
+	cmd, err := conn.beginRequest("CmdList")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
 	if err = conn.PrintfLine("LIST"); err != nil {
 		err = fmt.Errorf("[nntp.CmdList] failed to send LIST command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdList] failed to read LIST response: %w", err)
@@ -677,6 +872,11 @@ func (conn *Conn) CmdList() (groups []GroupListItem, err error) {
 
 // Fetches a list of (all) avaible newsgroups.
 func (conn *Conn) CmdListActive(wildmat string) (groups []GroupListItem, err error) {
+	cmd, err := conn.beginRequest("CmdListActive")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
 	if wildmat != "" {
 		err = conn.PrintfLine("LIST ACTIVE %s", wildmat)
 	} else {
@@ -686,6 +886,9 @@ func (conn *Conn) CmdListActive(wildmat string) (groups []GroupListItem, err err
 		err = fmt.Errorf("[nntp.CmdListActive] failed to send LIST ACTIVE command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdListActive] failed to read LIST ACTIVE response: %w", err)
@@ -714,6 +917,11 @@ func (conn *Conn) CmdListActive(wildmat string) (groups []GroupListItem, err err
 
 // Fetches a list of (all) avaible newsgroup descriptions.
 func (conn *Conn) CmdListNewsgroups(wildmat string) (groups []GroupDescriptionListItem, err error) {
+	cmd, err := conn.beginRequest("CmdListNewsgroups")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
 	if wildmat != "" {
 		err = conn.PrintfLine("LIST NEWSGROUPS %s", wildmat)
 	} else {
@@ -723,6 +931,9 @@ func (conn *Conn) CmdListNewsgroups(wildmat string) (groups []GroupDescriptionLi
 		err = fmt.Errorf("[nntp.CmdListNewsgroups] failed to send LIST NEWSGROUPS command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdListNewsgroups] failed to read LIST NEWSGROUPS response: %w", err)
@@ -752,103 +963,528 @@ func (conn *Conn) CmdListNewsgroups(wildmat string) (groups []GroupDescriptionLi
 	return
 }
 
-// Fetches message header for specified articles.
-func (conn *Conn) CmdOver(options ...OverOption) rx.Observable[*ArticleOverview] {
-	return rx.Func(func(subscriber rx.Writer[*ArticleOverview]) (err error) {
-		opts := option.New(options)
-		if opts.messageID != "" {
-			err = conn.PrintfLine("OVER %s", opts.messageID.Full())
-		} else if opts.articleRange != nil {
-			err = conn.PrintfLine("OVER %s", opts.articleRange.String())
-		} else {
-			err = conn.PrintfLine("OVER")
-		}
-		if err != nil {
-			err = fmt.Errorf("[nntp.CmdOver] failed to send OVER command: %w", err)
-			return
-		}
-		code, msg, err := conn.ReadCodeLine(0)
-		if err != nil {
-			err = fmt.Errorf("[nntp.CmdOver] failed to read OVER response: %w", err)
+// Fetches the creation time and creator of the newsgroups matching the wildmat, or of all newsgroups if the wildmat is
+// empty.
+func (conn *Conn) CmdListActiveTimes(wildmat string) (groups []GroupCreationListItem, err error) {
+	cmd, err := conn.beginRequest("CmdListActiveTimes")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
+	if wildmat != "" {
+		err = conn.PrintfLine("LIST ACTIVE.TIMES %s", wildmat)
+	} else {
+		err = conn.PrintfLine("LIST ACTIVE.TIMES")
+	}
+	if err != nil {
+		err = fmt.Errorf("[nntp.CmdListActiveTimes] failed to send LIST ACTIVE.TIMES command: %w", err)
+		return
+	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
+	code, msg, err := conn.ReadCodeLine(0)
+	if err != nil {
+		err = fmt.Errorf("[nntp.CmdListActiveTimes] failed to read LIST ACTIVE.TIMES response: %w", err)
+		return
+	}
+	switch ResponseCode(code) {
+	case ResponseCodeInformationFollows: // 215
+		var lines []string
+		if lines, err = conn.ReadDotLines(); err != nil {
+			err = fmt.Errorf("[nntp.CmdListActiveTimes] failed to read LIST ACTIVE.TIMES response body: %w", err)
 			return
 		}
-		switch ResponseCode(code) {
-		case ResponseCodeOverviewFollows: // 224
-			reader := bufio.NewScanner(conn.DotReader())
-			var line string
-			for reader.Scan() {
-				line = reader.Text()
-				article := &ArticleOverview{}
-				fields := strings.Split(line, "\t")
-				if len(fields) < 8 {
-					err = fmt.Errorf("[nntp.CmdOver] invalid group description line %#v: %w", line, ErrorParsingResponse)
-					break
-				}
-				if article.ArticleNumber, err = strconv.Atoi(fields[0]); err != nil {
-					err = fmt.Errorf("[nntp.CmdOver] failed to parse article number %#v: %w", fields[0], ErrorParsingResponse)
-					break
-				}
-				article.Subject, article.From, article.Date, article.MessageID, article.References = fields[1], fields[2], Timestamp(fields[3]), MessageID(fields[4]), fields[5]
-				// bytes and lines are best effort values
-				article.Bytes, _ = strconv.ParseUint(fields[6], 10, 64)
-				article.Lines, _ = strconv.ParseUint(fields[7], 10, 64)
-				article.ExtraFields = fields[8:]
-				if !subscriber.Write(article) {
-					break
-				}
+		groups = make([]GroupCreationListItem, len(lines))
+		for i, line := range lines {
+			group := &groups[i]
+			fields := strings.Fields(line)
+			var created int64
+			if len(fields) < 3 {
+				err = fmt.Errorf("[nntp.CmdListActiveTimes] invalid group creation line %#v: %w", line, ErrorParsingResponse)
+				return
 			}
-		default:
-			err = fmt.Errorf("[nntp.CmdOver] unexpected response: %w", &Error{ResponseCode(code), msg})
+			if created, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+				err = fmt.Errorf("[nntp.CmdListActiveTimes] failed to parse creation time %#v: %w", line, ErrorParsingResponse)
+				return
+			}
+			group.Group, group.Created, group.Creator = fields[0], time.Unix(created, 0).UTC(), strings.Join(fields[2:], " ")
 		}
-		return
-	})
+	default:
+		err = fmt.Errorf("[nntp.CmdListActiveTimes] unexpected response: %w", &Error{ResponseCode(code), msg})
+	}
+	return
 }
 
-// Fetches message header for specified articles.
-func (conn *Conn) CmdXOver(options ...OverOption) rx.Observable[*ArticleOverview] {
-	return rx.Func(func(subscriber rx.Writer[*ArticleOverview]) (err error) {
-		opts := option.New(options)
-		if opts.messageID != "" {
-			err = conn.PrintfLine("XOVER %s", opts.messageID.Full())
-		} else if opts.articleRange != nil {
-			err = conn.PrintfLine("XOVER %s", opts.articleRange.String())
-		} else {
-			err = conn.PrintfLine("XOVER")
-		}
-		if err != nil {
-			err = fmt.Errorf("[nntp.CmdXOver] failed to send XOVER command: %w", err)
-			return
-		}
-		code, msg, err := conn.ReadCodeLine(0)
+// Fetches the default Distribution header values to use for newsgroups, see DistributionPatternListItem.
+func (conn *Conn) CmdListDistribPats() (patterns []DistributionPatternListItem, err error) {
+	cmd, err := conn.beginRequest("CmdListDistribPats")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
+	if err = conn.PrintfLine("LIST DISTRIB.PATS"); err != nil {
+		err = fmt.Errorf("[nntp.CmdListDistribPats] failed to send LIST DISTRIB.PATS command: %w", err)
+		return
+	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
+	code, msg, err := conn.ReadCodeLine(0)
+	if err != nil {
+		err = fmt.Errorf("[nntp.CmdListDistribPats] failed to read LIST DISTRIB.PATS response: %w", err)
+		return
+	}
+	switch ResponseCode(code) {
+	case ResponseCodeInformationFollows: // 215
+		var lines []string
+		if lines, err = conn.ReadDotLines(); err != nil {
+			err = fmt.Errorf("[nntp.CmdListDistribPats] failed to read LIST DISTRIB.PATS response body: %w", err)
+			return
+		}
+		patterns = make([]DistributionPatternListItem, len(lines))
+		for i, line := range lines {
+			pattern := &patterns[i]
+			fields := strings.SplitN(line, ":", 3)
+			if len(fields) < 3 {
+				err = fmt.Errorf("[nntp.CmdListDistribPats] invalid distribution pattern line %#v: %w", line, ErrorParsingResponse)
+				return
+			}
+			if pattern.Weight, err = strconv.Atoi(fields[0]); err != nil {
+				err = fmt.Errorf("[nntp.CmdListDistribPats] failed to parse weight %#v: %w", line, ErrorParsingResponse)
+				return
+			}
+			pattern.Wildmat, pattern.Distribution = fields[1], fields[2]
+		}
+	default:
+		err = fmt.Errorf("[nntp.CmdListDistribPats] unexpected response: %w", &Error{ResponseCode(code), msg})
+	}
+	return
+}
+
+// Fetches the values of the Distribution header known to the server along with their descriptions.
+func (conn *Conn) CmdListDistributions() (distributions []DistributionListItem, err error) {
+	cmd, err := conn.beginRequest("CmdListDistributions")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
+	if err = conn.PrintfLine("LIST DISTRIBUTIONS"); err != nil {
+		err = fmt.Errorf("[nntp.CmdListDistributions] failed to send LIST DISTRIBUTIONS command: %w", err)
+		return
+	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
+	code, msg, err := conn.ReadCodeLine(0)
+	if err != nil {
+		err = fmt.Errorf("[nntp.CmdListDistributions] failed to read LIST DISTRIBUTIONS response: %w", err)
+		return
+	}
+	switch ResponseCode(code) {
+	case ResponseCodeInformationFollows: // 215
+		var lines []string
+		if lines, err = conn.ReadDotLines(); err != nil {
+			err = fmt.Errorf("[nntp.CmdListDistributions] failed to read LIST DISTRIBUTIONS response body: %w", err)
+			return
+		}
+		distributions = make([]DistributionListItem, len(lines))
+		for i, line := range lines {
+			distribution := &distributions[i]
+			fields := strings.Fields(line)
+			if len(fields) < 1 {
+				err = fmt.Errorf("[nntp.CmdListDistributions] invalid distribution line %#v: %w", line, ErrorParsingResponse)
+				return
+			}
+			distribution.Distribution = fields[0]
+			distribution.Description = strings.Join(fields[1:], " ")
+		}
+	default:
+		err = fmt.Errorf("[nntp.CmdListDistributions] unexpected response: %w", &Error{ResponseCode(code), msg})
+	}
+	return
+}
+
+// Fetches the submission addresses of moderated newsgroups, see ModeratorListItem.
+func (conn *Conn) CmdListModerators() (moderators []ModeratorListItem, err error) {
+	cmd, err := conn.beginRequest("CmdListModerators")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
+	if err = conn.PrintfLine("LIST MODERATORS"); err != nil {
+		err = fmt.Errorf("[nntp.CmdListModerators] failed to send LIST MODERATORS command: %w", err)
+		return
+	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
+	code, msg, err := conn.ReadCodeLine(0)
+	if err != nil {
+		err = fmt.Errorf("[nntp.CmdListModerators] failed to read LIST MODERATORS response: %w", err)
+		return
+	}
+	switch ResponseCode(code) {
+	case ResponseCodeInformationFollows: // 215
+		var lines []string
+		if lines, err = conn.ReadDotLines(); err != nil {
+			err = fmt.Errorf("[nntp.CmdListModerators] failed to read LIST MODERATORS response body: %w", err)
+			return
+		}
+		moderators = make([]ModeratorListItem, len(lines))
+		for i, line := range lines {
+			moderator := &moderators[i]
+			var ok bool
+			if moderator.Pattern, moderator.Address, ok = strings.Cut(line, ":"); !ok {
+				err = fmt.Errorf("[nntp.CmdListModerators] invalid moderator line %#v: %w", line, ErrorParsingResponse)
+				return
+			}
+		}
+	default:
+		err = fmt.Errorf("[nntp.CmdListModerators] unexpected response: %w", &Error{ResponseCode(code), msg})
+	}
+	return
+}
+
+// Fetches the message of the day of the server, UTF-8 text meant to be shown to users.
+func (conn *Conn) CmdListMotd() (motd io.Reader, err error) {
+	cmd, err := conn.beginRequest("CmdListMotd")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
+	if err = conn.PrintfLine("LIST MOTD"); err != nil {
+		err = fmt.Errorf("[nntp.CmdListMotd] failed to send LIST MOTD command: %w", err)
+		return
+	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
+	code, msg, err := conn.ReadCodeLine(0)
+	if err != nil {
+		err = fmt.Errorf("[nntp.CmdListMotd] failed to read LIST MOTD response: %w", err)
+		return
+	}
+	switch ResponseCode(code) {
+	case ResponseCodeInformationFollows: // 215
+		motd = cmd.bodyReader(conn.DotReader())
+	default:
+		err = fmt.Errorf("[nntp.CmdListMotd] unexpected response: %w", &Error{ResponseCode(code), msg})
+	}
+	return
+}
+
+// Fetches the newsgroups the server recommends new users to subscribe to, matching the wildmat, or all of them if the
+// wildmat is empty.
+func (conn *Conn) CmdListSubscriptions(wildmat string) (groups []string, err error) {
+	cmd, err := conn.beginRequest("CmdListSubscriptions")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
+	if wildmat != "" {
+		err = conn.PrintfLine("LIST SUBSCRIPTIONS %s", wildmat)
+	} else {
+		err = conn.PrintfLine("LIST SUBSCRIPTIONS")
+	}
+	if err != nil {
+		err = fmt.Errorf("[nntp.CmdListSubscriptions] failed to send LIST SUBSCRIPTIONS command: %w", err)
+		return
+	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
+	code, msg, err := conn.ReadCodeLine(0)
+	if err != nil {
+		err = fmt.Errorf("[nntp.CmdListSubscriptions] failed to read LIST SUBSCRIPTIONS response: %w", err)
+		return
+	}
+	switch ResponseCode(code) {
+	case ResponseCodeInformationFollows: // 215
+		var lines []string
+		if lines, err = conn.ReadDotLines(); err != nil {
+			err = fmt.Errorf("[nntp.CmdListSubscriptions] failed to read LIST SUBSCRIPTIONS response body: %w", err)
+			return
+		}
+		groups = lines
+	default:
+		err = fmt.Errorf("[nntp.CmdListSubscriptions] unexpected response: %w", &Error{ResponseCode(code), msg})
+	}
+	return
+}
+
+// Same as CmdListActive with the estimated count of articles in each group, using the LIST COUNTS command of INN.
+func (conn *Conn) CmdListCounts(wildmat string) (groups []GroupCountListItem, err error) {
+	cmd, err := conn.beginRequest("CmdListCounts")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
+	if wildmat != "" {
+		err = conn.PrintfLine("LIST COUNTS %s", wildmat)
+	} else {
+		err = conn.PrintfLine("LIST COUNTS")
+	}
+	if err != nil {
+		err = fmt.Errorf("[nntp.CmdListCounts] failed to send LIST COUNTS command: %w", err)
+		return
+	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
+	code, msg, err := conn.ReadCodeLine(0)
+	if err != nil {
+		err = fmt.Errorf("[nntp.CmdListCounts] failed to read LIST COUNTS response: %w", err)
+		return
+	}
+	switch ResponseCode(code) {
+	case ResponseCodeInformationFollows: // 215
+		var lines []string
+		if lines, err = conn.ReadDotLines(); err != nil {
+			err = fmt.Errorf("[nntp.CmdListCounts] failed to read LIST COUNTS response body: %w", err)
+			return
+		}
+		groups = make([]GroupCountListItem, len(lines))
+		for i, line := range lines {
+			group := &groups[i]
+			if _, err = fmt.Sscanf(line, "%s %d %d %d %c", &group.Group, &group.Last, &group.First, &group.Count, &group.Permission); err != nil {
+				err = fmt.Errorf("[nntp.CmdListCounts] failed to parse group count item %#v: %w", line, err)
+				return
+			}
+		}
+	default:
+		err = fmt.Errorf("[nntp.CmdListCounts] unexpected response: %w", &Error{ResponseCode(code), msg})
+	}
+	return
+}
+
+// Same as CmdList, emitting the groups as their lines arrive instead of collecting them, so that memory use does not
+// grow with the size of the active file. The subscriber may stop early, the rest of the list is then read and dropped
+// so that the connection stays in sync.
+func (conn *Conn) CmdStreamList() rx.Observable[*GroupListItem] {
+	return streamLines(conn, "CmdStreamList", "LIST", "LIST", ResponseCodeGroupsFollow, parseGroupListItem)
+}
+
+// Same as CmdListActive, emitting the groups as their lines arrive, see CmdStreamList.
+func (conn *Conn) CmdStreamListActive(wildmat string) rx.Observable[*GroupListItem] {
+	command := "LIST ACTIVE"
+	if wildmat != "" {
+		command += " " + wildmat
+	}
+	return streamLines(conn, "CmdStreamListActive", "LIST ACTIVE", command, ResponseCodeGroupsFollow, parseGroupListItem)
+}
+
+// Same as CmdListNewsgroups, emitting the descriptions as their lines arrive, see CmdStreamList.
+func (conn *Conn) CmdStreamListNewsgroups(wildmat string) rx.Observable[*GroupDescriptionListItem] {
+	command := "LIST NEWSGROUPS"
+	if wildmat != "" {
+		command += " " + wildmat
+	}
+	return streamLines(conn, "CmdStreamListNewsgroups", "LIST NEWSGROUPS", command, ResponseCodeGroupsFollow,
+		func(line string) (group *GroupDescriptionListItem, err error) {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				err = fmt.Errorf("invalid group description line %#v: %w", line, ErrorParsingResponse)
+				return
+			}
+			group = &GroupDescriptionListItem{Group: fields[0], Description: strings.Join(fields[1:], " ")}
+			return
+		})
+}
+
+// Same as CmdNewGroups, emitting the groups as their lines arrive, see CmdStreamList.
+func (conn *Conn) CmdStreamNewGroups(date time.Time, useGMT bool) rx.Observable[*GroupListItem] {
+	command := "NEWGROUPS " + date.Format("20060102 150405")
+	if useGMT {
+		command += " GMT"
+	}
+	return streamLines(conn, "CmdStreamNewGroups", "NEWGROUPS", command, ResponseCodeNewGroupsFollow, parseGroupListItem)
+}
+
+// Sends a command answered with a multi-line response, and emits the items parsed from its lines as they arrive.
+func streamLines[T any](conn *Conn, name, verb, command string, expected ResponseCode,
+	parse func(line string) (T, error)) rx.Observable[T] {
+	return rx.Func(func(subscriber rx.Writer[T]) (err error) {
+		cmd, err := conn.beginRequest(name)
 		if err != nil {
-			err = fmt.Errorf("[nntp.CmdXOver] failed to read XOVER response: %w", err)
+			return
+		}
+		defer cmd.end(&err)
+		if err = conn.PrintfLine("%s", command); err != nil {
+			err = fmt.Errorf("[nntp.%s] failed to send %s command: %w", name, verb, err)
+			return
+		}
+		if err = cmd.flush(); err != nil {
+			return
+		}
+		code, msg, err := conn.ReadCodeLine(0)
+		if err != nil {
+			err = fmt.Errorf("[nntp.%s] failed to read %s response: %w", name, verb, err)
+			return
+		}
+		switch ResponseCode(code) {
+		case expected:
+			err = conn.scanLines(func(line string) (more bool, err error) {
+				var item T
+				if item, err = parse(line); err != nil {
+					return
+				}
+				more = subscriber.Write(item)
+				return
+			})
+			if err != nil {
+				err = fmt.Errorf("[nntp.%s] %w", name, err)
+			}
+		default:
+			err = fmt.Errorf("[nntp.%s] unexpected response: %w", name, &Error{ResponseCode(code), msg})
+		}
+		return
+	})
+}
+
+// Parses a line of LIST ACTIVE or NEWGROUPS.
+func parseGroupListItem(line string) (group *GroupListItem, err error) {
+	group = &GroupListItem{}
+	if _, err = fmt.Sscanf(line, "%s %d %d %c", &group.Group, &group.Last, &group.First, &group.Permission); err != nil {
+		err = fmt.Errorf("failed to parse group list item %#v: %w", line, ErrorParsingResponse)
+		group = nil
+	}
+	return
+}
+
+// Fetches message header for specified articles.
+func (conn *Conn) CmdOver(options ...OverOption) rx.Observable[*ArticleOverview] {
+	return rx.Func(func(subscriber rx.Writer[*ArticleOverview]) (err error) {
+		opts := option.New(options)
+		if opts.messageID == "" && opts.articleSet != nil {
+			return eachRange(subscriber, opts.articleSet, func(r Range) rx.Observable[*ArticleOverview] {
+				return conn.CmdOver(WithArticleRange(r.First, r.Last), WithOverviewFormat(opts.overviewFormat))
+			})
+		}
+		if opts.messageID == "" {
+			if err = conn.requireGroup("CmdOver"); err != nil {
+				return
+			}
+		}
+		cmd, err := conn.beginRequest("CmdOver")
+		if err != nil {
+			return
+		}
+		defer cmd.end(&err)
+		if opts.messageID != "" {
+			err = conn.PrintfLine("OVER %s", opts.messageID.Full())
+		} else if opts.articleRange != nil {
+			err = conn.PrintfLine("OVER %s", opts.articleRange.String())
+		} else {
+			err = conn.PrintfLine("OVER")
+		}
+		if err != nil {
+			err = fmt.Errorf("[nntp.CmdOver] failed to send OVER command: %w", err)
+			return
+		}
+		if err = cmd.flush(); err != nil {
+			return
+		}
+		code, msg, err := conn.ReadCodeLine(0)
+		if err != nil {
+			err = fmt.Errorf("[nntp.CmdOver] failed to read OVER response: %w", err)
 			return
 		}
 		switch ResponseCode(code) {
 		case ResponseCodeOverviewFollows: // 224
-			reader := bufio.NewScanner(conn.DotReader())
-			var line string
-			for reader.Scan() {
-				line = reader.Text()
+			format := opts.overviewFormat
+			if format == nil {
+				format = conn.cachedOverviewFormat()
+			}
+			decoder := NewOverviewDecoder(conn.DotReader(), format)
+			for decoder.Next() {
 				article := &ArticleOverview{}
-				fields := strings.Split(line, "\t")
-				if len(fields) < 8 {
-					err = fmt.Errorf("[nntp.CmdXOver] invalid group description line %#v: %w", line, ErrorParsingResponse)
+				// the overviews are emitted, each one holding its own copy of the line
+				if err = decoder.decode(article, string(decoder.Line())); err != nil {
+					err = fmt.Errorf("[nntp.CmdOver] %w", err)
 					return
 				}
-				if article.ArticleNumber, err = strconv.Atoi(fields[0]); err != nil {
-					err = fmt.Errorf("[nntp.CmdXOver] failed to parse article number %#v: %w", fields[0], ErrorParsingResponse)
+				if !subscriber.Write(article) {
+					// the rest of the overviews is dropped so that the connection stays in sync
+					break
+				}
+			}
+			if err = decoder.Close(); err == nil {
+				err = decoder.Err()
+			}
+			if err != nil {
+				err = fmt.Errorf("[nntp.CmdOver] failed to read OVER response body: %w", err)
+			}
+		default:
+			err = fmt.Errorf("[nntp.CmdOver] unexpected response: %w", &Error{ResponseCode(code), msg})
+		}
+		return
+	})
+}
+
+// Fetches message header for specified articles.
+func (conn *Conn) CmdXOver(options ...OverOption) rx.Observable[*ArticleOverview] {
+	return rx.Func(func(subscriber rx.Writer[*ArticleOverview]) (err error) {
+		opts := option.New(options)
+		if opts.messageID == "" && opts.articleSet != nil {
+			return eachRange(subscriber, opts.articleSet, func(r Range) rx.Observable[*ArticleOverview] {
+				return conn.CmdXOver(WithArticleRange(r.First, r.Last), WithOverviewFormat(opts.overviewFormat))
+			})
+		}
+		if opts.messageID == "" {
+			if err = conn.requireGroup("CmdXOver"); err != nil {
+				return
+			}
+		}
+		cmd, err := conn.beginRequest("CmdXOver")
+		if err != nil {
+			return
+		}
+		defer cmd.end(&err)
+		if opts.messageID != "" {
+			err = conn.PrintfLine("XOVER %s", opts.messageID.Full())
+		} else if opts.articleRange != nil {
+			err = conn.PrintfLine("XOVER %s", opts.articleRange.String())
+		} else {
+			err = conn.PrintfLine("XOVER")
+		}
+		if err != nil {
+			err = fmt.Errorf("[nntp.CmdXOver] failed to send XOVER command: %w", err)
+			return
+		}
+		if err = cmd.flush(); err != nil {
+			return
+		}
+		code, msg, err := conn.ReadCodeLine(0)
+		if err != nil {
+			err = fmt.Errorf("[nntp.CmdXOver] failed to read XOVER response: %w", err)
+			return
+		}
+		switch ResponseCode(code) {
+		case ResponseCodeOverviewFollows: // 224
+			format := opts.overviewFormat
+			if format == nil {
+				format = conn.cachedOverviewFormat()
+			}
+			decoder := NewOverviewDecoder(conn.DotReader(), format)
+			for decoder.Next() {
+				article := &ArticleOverview{}
+				// the overviews are emitted, each one holding its own copy of the line
+				if err = decoder.decode(article, string(decoder.Line())); err != nil {
+					err = fmt.Errorf("[nntp.CmdXOver] %w", err)
 					return
 				}
-				article.Subject, article.From, article.Date, article.MessageID, article.References = fields[1], fields[2], Timestamp(fields[3]), MessageID(fields[4]), fields[5]
-				// bytes and lines are best effort values
-				article.Bytes, _ = strconv.ParseUint(fields[6], 10, 64)
-				article.Lines, _ = strconv.ParseUint(fields[7], 10, 64)
-				article.ExtraFields = fields[8:]
 				if !subscriber.Write(article) {
+					// the rest of the overviews is dropped so that the connection stays in sync
 					break
 				}
 			}
+			if err = decoder.Close(); err == nil {
+				err = decoder.Err()
+			}
+			if err != nil {
+				err = fmt.Errorf("[nntp.CmdXOver] failed to read XOVER response body: %w", err)
+			}
 		default:
 			err = fmt.Errorf("[nntp.CmdXOver] unexpected response: %w", &Error{ResponseCode(code), msg})
 		}
@@ -856,12 +1492,299 @@ func (conn *Conn) CmdXOver(options ...OverOption) rx.Observable[*ArticleOverview
 	})
 }
 
+// Same as CmdOver, returning a decoder reading the overview lines straight from the connection instead of a stream of
+// overviews, for bulk header synchronization, see OverviewDecoder. Like an article body, the decoder should be read to
+// the end or closed before the connection is used again. Articles cannot be selected with WithArticleSet.
+func (conn *Conn) CmdOverDecoder(options ...OverOption) (decoder *OverviewDecoder, err error) {
+	return conn.overviewDecoder("CmdOverDecoder", "OVER", options)
+}
+
+// Same as CmdOverDecoder with the XOVER command.
+func (conn *Conn) CmdXOverDecoder(options ...OverOption) (decoder *OverviewDecoder, err error) {
+	return conn.overviewDecoder("CmdXOverDecoder", "XOVER", options)
+}
+
+func (conn *Conn) overviewDecoder(name, verb string, options []OverOption) (decoder *OverviewDecoder, err error) {
+	opts := option.New(options)
+	if opts.articleSet != nil {
+		err = fmt.Errorf("[nntp.%s] article sets take several commands: %w", name, ErrorInvalidParams)
+		return
+	}
+	if opts.messageID == "" {
+		if err = conn.requireGroup(name); err != nil {
+			return
+		}
+	}
+	cmd, err := conn.beginRequest(name)
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
+	if opts.messageID != "" {
+		err = conn.PrintfLine("%s %s", verb, opts.messageID.Full())
+	} else if opts.articleRange != nil {
+		err = conn.PrintfLine("%s %s", verb, opts.articleRange.String())
+	} else {
+		err = conn.PrintfLine("%s", verb)
+	}
+	if err != nil {
+		err = fmt.Errorf("[nntp.%s] failed to send %s command: %w", name, verb, err)
+		return
+	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
+	code, msg, err := conn.ReadCodeLine(0)
+	if err != nil {
+		err = fmt.Errorf("[nntp.%s] failed to read %s response: %w", name, verb, err)
+		return
+	}
+	switch ResponseCode(code) {
+	case ResponseCodeOverviewFollows: // 224
+		format := opts.overviewFormat
+		if format == nil {
+			format = conn.cachedOverviewFormat()
+		}
+		decoder = NewOverviewDecoder(cmd.bodyReader(conn.DotReader()), format)
+	default:
+		err = fmt.Errorf("[nntp.%s] unexpected response: %w", name, &Error{ResponseCode(code), msg})
+	}
+	return
+}
+
+// Fetches the value of a single header field for the specified articles. The field name is given in the same form as
+// returned by CmdListHeaders, that is, either a header name such as "Subject", or a metadata item such as ":bytes".
+// When the articles are selected by message-id the article number of the only returned item is 0.
+func (conn *Conn) CmdHdr(field string, options ...OverOption) rx.Observable[*ArticleHeader] {
+	return rx.Func(func(subscriber rx.Writer[*ArticleHeader]) (err error) {
+		if !validHeaderField(field) {
+			err = fmt.Errorf("[nntp.CmdHdr] invalid header field %#v: %w", field, ErrorInvalidParams)
+			return
+		}
+		opts := option.New(options)
+		if opts.messageID == "" && opts.articleSet != nil {
+			return eachRange(subscriber, opts.articleSet, func(r Range) rx.Observable[*ArticleHeader] {
+				return conn.CmdHdr(field, WithArticleRange(r.First, r.Last))
+			})
+		}
+		if opts.messageID == "" {
+			if err = conn.requireGroup("CmdHdr"); err != nil {
+				return
+			}
+		}
+		cmd, err := conn.beginRequest("CmdHdr")
+		if err != nil {
+			return
+		}
+		defer cmd.end(&err)
+		if opts.messageID != "" {
+			err = conn.PrintfLine("HDR %s %s", field, opts.messageID.Full())
+		} else if opts.articleRange != nil {
+			err = conn.PrintfLine("HDR %s %s", field, opts.articleRange.String())
+		} else {
+			err = conn.PrintfLine("HDR %s", field)
+		}
+		if err != nil {
+			err = fmt.Errorf("[nntp.CmdHdr] failed to send HDR command: %w", err)
+			return
+		}
+		if err = cmd.flush(); err != nil {
+			return
+		}
+		code, msg, err := conn.ReadCodeLine(0)
+		if err != nil {
+			err = fmt.Errorf("[nntp.CmdHdr] failed to read HDR response: %w", err)
+			return
+		}
+		switch ResponseCode(code) {
+		case ResponseCodeHeadersFollow: // 225
+			if err = conn.readArticleHeaders(subscriber); err != nil {
+				err = fmt.Errorf("[nntp.CmdHdr] %w", err)
+			}
+		default:
+			err = fmt.Errorf("[nntp.CmdHdr] unexpected response: %w", &Error{ResponseCode(code), msg})
+		}
+		return
+	})
+}
+
+// Fetches the value of a single header field for the specified articles using the XHDR command from RFC 2980. Prefer
+// CmdHdr on servers advertising the HDR capability.
+func (conn *Conn) CmdXHdr(field string, options ...OverOption) rx.Observable[*ArticleHeader] {
+	return rx.Func(func(subscriber rx.Writer[*ArticleHeader]) (err error) {
+		if !validHeaderField(field) {
+			err = fmt.Errorf("[nntp.CmdXHdr] invalid header field %#v: %w", field, ErrorInvalidParams)
+			return
+		}
+		opts := option.New(options)
+		if opts.messageID == "" && opts.articleSet != nil {
+			return eachRange(subscriber, opts.articleSet, func(r Range) rx.Observable[*ArticleHeader] {
+				return conn.CmdXHdr(field, WithArticleRange(r.First, r.Last))
+			})
+		}
+		if opts.messageID == "" {
+			if err = conn.requireGroup("CmdXHdr"); err != nil {
+				return
+			}
+		}
+		cmd, err := conn.beginRequest("CmdXHdr")
+		if err != nil {
+			return
+		}
+		defer cmd.end(&err)
+		if opts.messageID != "" {
+			err = conn.PrintfLine("XHDR %s %s", field, opts.messageID.Full())
+		} else if opts.articleRange != nil {
+			err = conn.PrintfLine("XHDR %s %s", field, opts.articleRange.String())
+		} else {
+			err = conn.PrintfLine("XHDR %s", field)
+		}
+		if err != nil {
+			err = fmt.Errorf("[nntp.CmdXHdr] failed to send XHDR command: %w", err)
+			return
+		}
+		if err = cmd.flush(); err != nil {
+			return
+		}
+		code, msg, err := conn.ReadCodeLine(0)
+		if err != nil {
+			err = fmt.Errorf("[nntp.CmdXHdr] failed to read XHDR response: %w", err)
+			return
+		}
+		switch ResponseCode(code) {
+		case ResponseCodeHeadFollows: // 221
+			if err = conn.readArticleHeaders(subscriber); err != nil {
+				err = fmt.Errorf("[nntp.CmdXHdr] %w", err)
+			}
+		default:
+			err = fmt.Errorf("[nntp.CmdXHdr] unexpected response: %w", &Error{ResponseCode(code), msg})
+		}
+		return
+	})
+}
+
+// Issues the command built by cmd for each range of the set in turn, emitting what it emits. Ranges holding no article
+// anymore, answered with 423 or 420 by servers predating RFC 3977, are skipped.
+func eachRange[T any](subscriber rx.Writer[T], set *ArticleSet, cmd func(Range) rx.Observable[T]) (err error) {
+	for _, r := range set.Ranges() {
+		writer, reader := rx.Pipe[T](subscriber, rx.PipeWithDiscardChildError())
+		cmd(r).Subscribe(writer)
+		for {
+			item, ok := reader.Read()
+			if !ok {
+				break
+			}
+			if !subscriber.Write(item) {
+				return
+			}
+		}
+		if err = reader.Wait(); errors.Is(err, ResponseCodeNoSuchArticleNumber) || errors.Is(err, ResponseCodeNoArticleSelected) {
+			err = nil
+		} else if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Returns the overview format cached by CmdListOverviewFmt, nil if unknown.
+func (conn *Conn) cachedOverviewFormat() []OverviewFieldFormat {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	return conn.overviewFormat
+}
+
+// Reads the "<article number> <value>" lines of a HDR or XHDR response body.
+func (conn *Conn) readArticleHeaders(subscriber rx.Writer[*ArticleHeader]) (err error) {
+	return conn.scanLines(func(line string) (more bool, err error) {
+		number, value, _ := strings.Cut(line, " ")
+		header := &ArticleHeader{Value: value}
+		if header.ArticleNumber, err = parseArticleNumber(number); err != nil {
+			err = fmt.Errorf("failed to parse article number %#v: %w", number, ErrorParsingResponse)
+			return
+		}
+		more = subscriber.Write(header)
+		return
+	})
+}
+
+// Reads the lines of a multi-line response body with f, until f stops or fails. When f stops early, typically because
+// the subscriber of a stream is gone, the rest of the body is read and dropped so that the connection stays in sync.
+// Lines may be of any length.
+func (conn *Conn) scanLines(f func(line string) (more bool, err error)) (err error) {
+	r := bufio.NewReader(conn.DotReader())
+	var buf []byte
+	for more := true; ; {
+		line, e := readLine(r, &buf)
+		if e == io.EOF {
+			return
+		} else if e != nil {
+			err = fmt.Errorf("failed to read response body: %w", e)
+			return
+		}
+		if !more {
+			continue
+		}
+		if more, err = f(string(line)); err != nil {
+			// a malformed response leaves the connection unusable, there is no point reading the rest
+			return
+		}
+	}
+}
+
+// Reads a line of any length, without its line terminator. Lines longer than the buffer of r are gathered in buf, so
+// that the line is only valid until the next call. The last line may miss its line terminator.
+func readLine(r *bufio.Reader, buf *[]byte) (line []byte, err error) {
+	line, err = r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		*buf = append((*buf)[:0], line...)
+		for err == bufio.ErrBufferFull {
+			line, err = r.ReadSlice('\n')
+			*buf = append(*buf, line...)
+		}
+		line = *buf
+	}
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	if err != nil {
+		return
+	}
+	line = bytes.TrimSuffix(line, []byte{'\n'})
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	return
+}
+
+// Parses an article number, which RFC 3977 allows to range up to 2^63-1. Values out of that range, signed or not purely
+// decimal are rejected instead of wrapping around.
+func parseArticleNumber(s string) (articleNumber int64, err error) {
+	if s == "" || s[0] == '+' || s[0] == '-' {
+		err = strconv.ErrSyntax
+		return
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// Header field names must not be empty nor contain whitespaces, since they are sent as a command argument. The fields
+// listed by CmdListHeaders are checked the same way.
+func validHeaderField(field string) bool {
+	return field != "" && field != ":" && !strings.ContainsAny(field, " \t\r\n")
+}
+
 // Fetches description of the fields returned in OVER or XOVER command.
 func (conn *Conn) CmdListOverviewFmt() (fields []OverviewFieldFormat, err error) {
+	cmd, err := conn.beginRequest("CmdListOverviewFmt")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
 	if err = conn.PrintfLine("LIST OVERVIEW.FMT"); err != nil {
 		err = fmt.Errorf("[nntp.CmdListOverviewFmt] failed to send LIST OVERVIEW.FMT command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdListOverviewFmt] failed to read LIST OVERVIEW.FMT response: %w", err)
@@ -919,18 +1842,31 @@ func (conn *Conn) CmdListOverviewFmt() (fields []OverviewFieldFormat, err error)
 				field.Name, field.Type = parts[0], ShortHeaderOverviewField
 			}
 		}
+		conn.mu.Lock()
+		conn.overviewFormat = fields
+		conn.mu.Unlock()
 	default:
 		err = fmt.Errorf("[nntp.CmdListOverviewFmt] unexpected response: %w", &Error{ResponseCode(code), msg})
 	}
 	return
 }
 
-// Fetches list of fields that may be retrieved using the HDR command.
+// Fetches list of fields that may be retrieved using the HDR command. Each field is checked the way CmdHdr checks its
+// field argument, so that every field returned can be given to CmdHdr or CmdXHdr. The line made of a single colon, by
+// which the server tells that any header may be retrieved, sets anyField instead.
 func (conn *Conn) CmdListHeaders() (anyField bool, fields []string, err error) {
+	cmd, err := conn.beginRequest("CmdListHeaders")
+	if err != nil {
+		return
+	}
+	defer cmd.end(&err)
 	if err = conn.PrintfLine("LIST HEADERS"); err != nil {
 		err = fmt.Errorf("[nntp.CmdListHeaders] failed to send LIST HEADERS command: %w", err)
 		return
 	}
+	if err = cmd.flush(); err != nil {
+		return
+	}
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.CmdListHeaders] failed to read LIST HEADERS response: %w", err)
@@ -943,12 +1879,15 @@ func (conn *Conn) CmdListHeaders() (anyField bool, fields []string, err error) {
 			err = fmt.Errorf("[nntp.CmdListHeaders] failed to read LIST HEADERS response body: %w", err)
 			return
 		}
-		if len(lines) > 0 {
-			if lines[0] == ":" {
+		for _, line := range lines {
+			if line == ":" {
 				anyField = true
-				fields = lines[1:]
+			} else if validHeaderField(line) {
+				fields = append(fields, line)
 			} else {
-				fields = lines
+				err = fmt.Errorf("[nntp.CmdListHeaders] invalid header field %#v: %w", line, ErrorParsingResponse)
+				fields = nil
+				return
 			}
 		}
 	default:
@@ -964,10 +1903,19 @@ func (conn *Conn) CmdListHeaders() (anyField bool, fields []string, err error) {
  * @param string $pass The password to authenticate with.
  */
 func (conn *Conn) CmdAuthinfo(user, pass string) (err error) {
+	end, err := conn.begin("CmdAuthinfo")
+	if err != nil {
+		return
+	}
+	defer end(&err)
 	if user == "" {
 		err = fmt.Errorf("[nntp.CmdAuthinfo] empty username: %w", ErrorInvalidParams)
 		return
 	}
+	if conn.requireTLS && !conn.IsTLS() {
+		err = fmt.Errorf("[nntp.CmdAuthinfo] refusing to send credentials in the clear: %w", ErrorTLSRequired)
+		return
+	}
 
 	// send the username
 	if err = conn.PrintfLine("AUTHINFO user %s", user); err != nil {
@@ -995,7 +1943,10 @@ func (conn *Conn) CmdAuthinfo(user, pass string) (err error) {
 	}
 	switch ResponseCode(code) {
 	case ResponseCodeAuthenticationAccepted: // 281
-		err = nil
+		conn.setCapabilities(nil)
+		conn.updateState(func(state *SessionState) {
+			state.Authenticated = true
+		})
 	case ResponseCodeAuthenticationContinue: // 381
 		err = fmt.Errorf("[nntp.CmdAuthinfo] authentication uncompleted: %w", &Error{ResponseCode(code), msg})
 	case ResponseCodeAuthenticationRejected, ResponseCodeNotPermitted: // 482 || 502
@@ -1005,3 +1956,162 @@ func (conn *Conn) CmdAuthinfo(user, pass string) (err error) {
 	}
 	return
 }
+
+// Authenticate using the AUTHINFO SASL command from RFC 4643. The mechanisms are given in order of preference and the
+// first one advertised in the SASL capability is used. An exchange failing on the client side is cancelled, while an
+// authentication the server accepts but the mechanism does not trust, see SASLCompleter, leaves the connection
+// unusable, since the server considers the session authenticated.
+func (conn *Conn) CmdAuthinfoSASL(mechanisms ...SASLMechanism) (err error) {
+	if len(mechanisms) == 0 {
+		err = fmt.Errorf("[nntp.CmdAuthinfoSASL] no SASL mechanism: %w", ErrorInvalidParams)
+		return
+	}
+	if conn.requireTLS && !conn.IsTLS() {
+		err = fmt.Errorf("[nntp.CmdAuthinfoSASL] refusing to authenticate in the clear: %w", ErrorTLSRequired)
+		return
+	}
+	capabilities, err := conn.Capabilities()
+	if err != nil {
+		err = fmt.Errorf("[nntp.CmdAuthinfoSASL] failed to read capabilities: %w", err)
+		return
+	}
+	advertised, ok := capabilities.Args("SASL")
+	if !ok {
+		err = fmt.Errorf("[nntp.CmdAuthinfoSASL] SASL: %w", ErrorCapabilityMissing)
+		return
+	}
+	var mechanism SASLMechanism
+	for _, m := range mechanisms {
+		for _, name := range advertised {
+			if strings.EqualFold(m.Name(), name) {
+				mechanism = m
+				break
+			}
+		}
+		if mechanism != nil {
+			break
+		}
+	}
+	if mechanism == nil {
+		err = fmt.Errorf("[nntp.CmdAuthinfoSASL] none of the SASL mechanisms in %v: %w", advertised, ErrorCapabilityMissing)
+		return
+	}
+
+	ir, err := mechanism.Start()
+	if err != nil {
+		err = fmt.Errorf("[nntp.CmdAuthinfoSASL] failed to start %s exchange: %w", mechanism.Name(), err)
+		return
+	}
+	end, err := conn.begin("CmdAuthinfoSASL")
+	if err != nil {
+		return
+	}
+	defer end(&err)
+	command := "AUTHINFO SASL " + mechanism.Name()
+	if ir != nil {
+		// the initial response is deferred to the first empty challenge when it doesn't fit in the command line
+		if encoded := saslEncode(ir); len(command)+1+len(encoded) <= 497 {
+			command, ir = command+" "+encoded, nil
+		}
+	}
+	if err = conn.PrintfLine("%s", command); err != nil {
+		err = fmt.Errorf("[nntp.CmdAuthinfoSASL] failed to send AUTHINFO SASL command: %w", err)
+		return
+	}
+	for {
+		code, msg, e := conn.ReadCodeLine(0)
+		if e != nil {
+			err = fmt.Errorf("[nntp.CmdAuthinfoSASL] failed to read AUTHINFO SASL response: %w", e)
+			return
+		}
+		switch ResponseCode(code) {
+		case ResponseCodeSASLContinue: // 383
+			var challenge, response []byte
+			if challenge, err = saslDecode(msg); err != nil {
+				err = fmt.Errorf("[nntp.CmdAuthinfoSASL] failed to decode challenge %#v: %w", msg, ErrorSASLUnexpectedChallenge)
+				err = conn.cancelSASL(err)
+				return
+			}
+			if ir != nil && len(challenge) == 0 {
+				response, ir = ir, nil
+			} else if response, err = mechanism.Next(challenge); err != nil {
+				err = fmt.Errorf("[nntp.CmdAuthinfoSASL] %s exchange failed: %w", mechanism.Name(), err)
+				err = conn.cancelSASL(err)
+				return
+			}
+			if err = conn.PrintfLine("%s", saslEncode(response)); err != nil {
+				err = fmt.Errorf("[nntp.CmdAuthinfoSASL] failed to send SASL response: %w", err)
+				return
+			}
+			continue
+		case ResponseCodeAuthenticationAccepted, ResponseCodeAuthenticationAcceptedWithData: // 281 || 283
+			conn.setCapabilities(nil)
+			if ResponseCode(code) == ResponseCodeAuthenticationAcceptedWithData {
+				var data []byte
+				if data, err = saslDecode(msg); err != nil {
+					err = fmt.Errorf("[nntp.CmdAuthinfoSASL] failed to decode success data %#v: %w", msg, err)
+				} else if _, err = mechanism.Next(data); err != nil {
+					err = fmt.Errorf("[nntp.CmdAuthinfoSASL] %s server verification failed: %w", mechanism.Name(), err)
+				}
+			}
+			if err == nil {
+				err = saslComplete(mechanism)
+			}
+			if err != nil {
+				// the server considers the session authenticated while the client does not trust it
+				conn.kill(err)
+				return
+			}
+			conn.updateState(func(state *SessionState) {
+				state.Authenticated = true
+			})
+		case ResponseCodeAuthenticationFailed, ResponseCodeAuthenticationRejected, ResponseCodeNotPermitted: // 481 || 482 || 502
+			err = fmt.Errorf("[nntp.CmdAuthinfoSASL] authentication rejected: %w", &Error{ResponseCode(code), msg})
+		case ResponseCodeBase64EncodingError: // 504
+			err = fmt.Errorf("[nntp.CmdAuthinfoSASL] server failed to decode response: %w", &Error{ResponseCode(code), msg})
+		default:
+			err = fmt.Errorf("[nntp.CmdAuthinfoSASL] unexpected response: %w", &Error{ResponseCode(code), msg})
+		}
+		return
+	}
+}
+
+// Fails when the server accepted the authentication before the end of the exchange of the mechanism, see SASLCompleter.
+func saslComplete(mechanism SASLMechanism) (err error) {
+	if completer, ok := mechanism.(SASLCompleter); ok && !completer.Complete() {
+		err = fmt.Errorf("[nntp.CmdAuthinfoSASL] %s exchange accepted before the server proved its identity: %w",
+			mechanism.Name(), ErrorSASLUnexpectedChallenge)
+	}
+	return
+}
+
+// Aborts the SASL exchange that failed with cause by sending "*", and reads the server's final 481 response. It returns
+// cause once the connection is back in sync, see inSync.
+func (conn *Conn) cancelSASL(cause error) (err error) {
+	if err = conn.PrintfLine("*"); err != nil {
+		err = fmt.Errorf("[nntp.CmdAuthinfoSASL] failed to cancel SASL exchange: %w", err)
+		return
+	}
+	if _, _, err = conn.ReadCodeLine(0); err != nil {
+		err = fmt.Errorf("[nntp.CmdAuthinfoSASL] failed to read SASL cancellation response: %w", err)
+		return
+	}
+	err = syncedError{cause}
+	return
+}
+
+// Empty SASL messages are sent as a single "=" to distinguish them from absent ones.
+func saslEncode(data []byte) string {
+	if len(data) == 0 {
+		return "="
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func saslDecode(msg string) (data []byte, err error) {
+	msg = strings.TrimSpace(msg)
+	if msg == "" || msg == "=" {
+		return
+	}
+	return base64.StdEncoding.DecodeString(msg)
+}
@@ -0,0 +1,98 @@
+package nntp
+
+import (
+	"compress/flate"
+	"fmt"
+	"io"
+
+	"gopkg.in/textproto.v0"
+)
+
+// Reports whether the connection is compressed with COMPRESS DEFLATE.
+func (conn *Conn) IsCompressed() bool {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	return conn.compressed
+}
+
+// Activates COMPRESS DEFLATE as described in RFC 8054. Once the server accepts, every later command and response goes
+// through a deflate stream. Compression is layered inside TLS, so a connection meant to be secured must be upgraded
+// with StartTLS first. It is refused without sending any command if the server does not advertise the DEFLATE
+// algorithm in its COMPRESS capability.
+func (conn *Conn) Compress() (err error) {
+	if conn.IsCompressed() {
+		err = fmt.Errorf("[nntp.Compress] compression already active: %w", ErrorInvalidParams)
+		return
+	}
+	capabilities, err := conn.Capabilities()
+	if err != nil {
+		err = fmt.Errorf("[nntp.Compress] failed to read capabilities: %w", err)
+		return
+	}
+	if !capabilities.Has("COMPRESS", "DEFLATE") {
+		err = fmt.Errorf("[nntp.Compress] COMPRESS DEFLATE: %w", ErrorCapabilityMissing)
+		return
+	}
+	end, err := conn.begin("Compress")
+	if err != nil {
+		return
+	}
+	defer end(&err)
+	// checked again once running alone, so that concurrent callers cannot stack two deflate layers
+	if conn.IsCompressed() {
+		err = fmt.Errorf("[nntp.Compress] compression already active: %w", ErrorInvalidParams)
+		return
+	}
+	if err = conn.PrintfLine("COMPRESS DEFLATE"); err != nil {
+		err = fmt.Errorf("[nntp.Compress] failed to send COMPRESS command: %w", err)
+		return
+	}
+	code, msg, err := conn.ReadCodeLine(0)
+	if err != nil {
+		err = fmt.Errorf("[nntp.Compress] failed to read COMPRESS response: %w", err)
+		return
+	}
+	switch ResponseCode(code) {
+	case ResponseCodeCompressionActive: // 206
+		err = nil
+	case ResponseCodeInternalFault, ResponseCodeNotPermitted: // 403 || 502
+		err = fmt.Errorf("[nntp.Compress] compression refused: %w", &Error{ResponseCode(code), msg})
+		return
+	default:
+		err = fmt.Errorf("[nntp.Compress] unexpected response: %w", &Error{ResponseCode(code), msg})
+		return
+	}
+	w, _ := flate.NewWriter(conn.netconn, flate.DefaultCompression)
+	// the deflate stream reads from the current buffered reader, so that nothing already buffered gets lost
+	conn.mu.Lock()
+	conn.Conn = textproto.NewConn(&deflateConn{flate.NewReader(conn.R), w, conn.netconn})
+	conn.compressed, conn.capabilities = true, nil
+	conn.mu.Unlock()
+	return
+}
+
+// A deflateConn compresses writes and decompresses reads over an underlying transport.
+type deflateConn struct {
+	r       io.ReadCloser
+	w       *flate.Writer
+	netconn io.ReadWriteCloser
+}
+
+func (c *deflateConn) Read(b []byte) (n int, err error) {
+	return c.r.Read(b)
+}
+
+// Every write is followed by a sync flush, since the textproto writer only writes complete commands and the server
+// cannot process a command until its last byte gets out of the compressor.
+func (c *deflateConn) Write(b []byte) (n int, err error) {
+	if n, err = c.w.Write(b); err != nil {
+		return
+	}
+	err = c.w.Flush()
+	return
+}
+
+func (c *deflateConn) Close() error {
+	c.r.Close()
+	return c.netconn.Close()
+}
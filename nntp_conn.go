@@ -1,25 +1,110 @@
 package nntp
 
 import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"sync"
 
 	"gopkg.in/textproto.v0"
 )
 
+// A Conn is a client connection to an NNTP server. Connections returned by WithContext are views sharing the same
+// session, and differ only in the context their commands are bound to.
+//
+// A Conn is safe for concurrent use. Commands are queued in the order they are issued and their responses are read in
+// the same order: single line requests are pipelined, being sent while the responses of earlier commands are still
+// being read, while commands made of several requests or changing the session state run alone. Concurrent callers
+// reading article bodies should each use their own view, so that their bodies are not drained by the commands of
+// others, see Article.Body.
 type Conn struct {
+	*session
+
+	// Bounds every command issued through this view, see WithContext.
+	ctx context.Context
+}
+
+// The state shared by all the views of a connection.
+type session struct {
 	*textproto.Conn
+
+	// The transport underneath the textproto stream, replaced when the connection is upgraded to TLS.
+	netconn io.ReadWriteCloser
+
+	// Refuse to send AUTHINFO credentials until the connection is upgraded to TLS.
+	requireTLS bool
+
+	// Capabilities cached until they may change, see Capabilities.
+	capabilities *Capabilities
+
+	// Whether the textproto stream runs through a COMPRESS DEFLATE layer.
+	compressed bool
+
+	// Orders the commands of concurrent callers, see begin. It is kept apart from the textproto connection, which is
+	// replaced by StartTLS and Compress.
+	pipeline textproto.Pipeline
+
+	// Guards the fields below, along with the ones above that commands change: the textproto connection, netconn,
+	// capabilities and compressed.
+	mu sync.Mutex
+
+	// Set once the connection is left in an unknown protocol state, see Err.
+	broken error
+
+	// The article body of the last command, holding the response turn until it is read, see call.bodyReader.
+	body *pendingBody
+
+	// The state tracked from successful commands, see State, and the number of commands in flight that may select a
+	// group, see requireGroup.
+	state         SessionState
+	groupsPending int
+
+	// The overview format read by the last CmdListOverviewFmt, naming the extra fields of CmdOver and CmdXOver.
+	overviewFormat []OverviewFieldFormat
+
+	// The commands answered with 500 by the server, see Overview.
+	unknownCommands map[string]bool
 }
 
 func (conn *Conn) Close() error {
-	return conn.Conn.Close()
+	conn.mu.Lock()
+	c := conn.Conn
+	conn.mu.Unlock()
+	return c.Close()
 }
 
 func NewConn(conn io.ReadWriteCloser) *Conn {
-	return &Conn{textproto.NewConn(conn)}
+	return &Conn{&session{Conn: textproto.NewConn(conn), netconn: conn}, context.Background()}
+}
+
+// Returns a reader of the dot-encoded block that follows, see textproto.Reader.DotReader. The reader of textproto fails
+// with bufio.ErrBufferFull on lines longer than its buffer, having read part of the line; reading on returns the rest
+// of the line, so the error is dropped. Only Read is exposed, as textproto's WriteTo reads past the end of the block.
+func (s *session) DotReader(options ...textproto.DotReaderOption) io.Reader {
+	return &dotReader{s.Conn.DotReader(options...)}
+}
+
+type dotReader struct {
+	r io.Reader
+}
+
+func (r *dotReader) Read(p []byte) (n int, err error) {
+	if n, err = r.r.Read(p); errors.Is(err, bufio.ErrBufferFull) {
+		err = nil
+	}
+	return
 }
 
 func (conn *Conn) ReadWelcome() (err error) {
+	end, err := conn.begin("readWelcome")
+	if err != nil {
+		return
+	}
+	defer end(&err)
 	code, msg, err := conn.ReadCodeLine(0)
 	if err != nil {
 		err = fmt.Errorf("[nntp.readWelcome] failed to read Welcome message: %w", err)
@@ -27,9 +112,75 @@ func (conn *Conn) ReadWelcome() (err error) {
 	}
 	switch ResponseCode(code) {
 	case ResponseCodeReadyPostingAllowed, ResponseCodeReadyPostingProhibited: // 200 || 201
-		err = nil
+		conn.updateState(func(state *SessionState) {
+			state.PostingAllowed = ResponseCode(code) == ResponseCodeReadyPostingAllowed
+		})
 	default:
 		err = fmt.Errorf("[nntp.readWelcome] unexpected response: %w", &Error{ResponseCode(code), msg})
 	}
 	return
 }
+
+// Reports whether the connection is protected by TLS, either dialed with implicit TLS or upgraded with StartTLS.
+func (conn *Conn) IsTLS() bool {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	_, ok := conn.netconn.(*tls.Conn)
+	return ok
+}
+
+// Upgrades the connection to TLS as described in RFC 4642. The textproto stream is replaced by one running over the TLS
+// connection and any capabilities read before the upgrade are discarded, since the server may advertise different
+// capabilities once the connection is secured. A failed handshake leaves the connection unusable and it should be
+// closed.
+func (conn *Conn) StartTLS(config *tls.Config) (err error) {
+	end, err := conn.begin("StartTLS")
+	if err != nil {
+		return
+	}
+	defer end(&err)
+	// checked once running alone, so that a pipelined COMPRESS cannot complete in between
+	if conn.IsCompressed() {
+		// RFC 8054 requires TLS to be negotiated before compression, never after
+		err = fmt.Errorf("[nntp.StartTLS] cannot negotiate TLS on a compressed connection: %w", ErrorInvalidParams)
+		return
+	}
+	netconn, ok := conn.netconn.(net.Conn)
+	if !ok {
+		err = fmt.Errorf("[nntp.StartTLS] underlying connection %T cannot be upgraded to TLS: %w", conn.netconn, ErrorInvalidParams)
+		return
+	}
+	if err = conn.PrintfLine("STARTTLS"); err != nil {
+		err = fmt.Errorf("[nntp.StartTLS] failed to send STARTTLS command: %w", err)
+		return
+	}
+	code, msg, err := conn.ReadCodeLine(0)
+	if err != nil {
+		err = fmt.Errorf("[nntp.StartTLS] failed to read STARTTLS response: %w", err)
+		return
+	}
+	switch ResponseCode(code) {
+	case ResponseCodeTLSContinue: // 382
+		err = nil
+	case ResponseCodeTLSFailure, ResponseCodeNotPermitted: // 580 || 502
+		err = fmt.Errorf("[nntp.StartTLS] TLS negotiation refused: %w", &Error{ResponseCode(code), msg})
+		return
+	default:
+		err = fmt.Errorf("[nntp.StartTLS] unexpected response: %w", &Error{ResponseCode(code), msg})
+		return
+	}
+	if n := conn.R.Buffered(); n > 0 {
+		err = fmt.Errorf("[nntp.StartTLS] %d bytes received before TLS negotiation: %w", n, ErrorParsingResponse)
+		return
+	}
+	tlsconn := tls.Client(netconn, config)
+	if err = tlsconn.HandshakeContext(conn.ctx); err != nil {
+		err = fmt.Errorf("[nntp.StartTLS] TLS handshake failed: %w", err)
+		return
+	}
+	conn.mu.Lock()
+	conn.Conn, conn.netconn = textproto.NewConn(tlsconn), tlsconn
+	conn.capabilities = nil
+	conn.mu.Unlock()
+	return
+}
@@ -149,6 +149,38 @@ const (
 	 */
 	ResponseCodeTransferRejected ResponseCode = 437
 
+	// Streaming
+
+	/**
+	 * 'Streaming permitted' (RFC4644)
+	 */
+	ResponseCodeStreamingPermitted ResponseCode = 203
+
+	/**
+	 * 'Send article to be transferred' (RFC4644)
+	 */
+	ResponseCodeCheckSend ResponseCode = 238
+
+	/**
+	 * 'Transfer not possible; try again later' (RFC4644)
+	 */
+	ResponseCodeCheckLater ResponseCode = 431
+
+	/**
+	 * 'Article not wanted' (RFC4644)
+	 */
+	ResponseCodeCheckUnwanted ResponseCode = 438
+
+	/**
+	 * 'Article transferred OK' (RFC4644)
+	 */
+	ResponseCodeTakeThisSuccess ResponseCode = 239
+
+	/**
+	 * 'Transfer rejected; do not retry' (RFC4644)
+	 */
+	ResponseCodeTakeThisRejected ResponseCode = 439
+
 	// Posting
 
 	/**
@@ -215,6 +247,42 @@ const (
 	 */
 	ResponseCodeAuthenticationRejected ResponseCode = 482
 
+	// Simple authentication and security layer
+
+	/**
+	 * 'Authentication accepted (with success data)' (RFC4643)
+	 */
+	ResponseCodeAuthenticationAcceptedWithData ResponseCode = 283
+
+	/**
+	 * 'Continue with SASL exchange' (RFC4643)
+	 */
+	ResponseCodeSASLContinue ResponseCode = 383
+
+	/**
+	 * 'Authentication failed/rejected' (RFC4643)
+	 */
+	ResponseCodeAuthenticationFailed ResponseCode = 481
+
+	// Transport layer security
+
+	/**
+	 * 'Continue with TLS negotiation' (RFC4642)
+	 */
+	ResponseCodeTLSContinue ResponseCode = 382
+
+	/**
+	 * 'Can not initiate TLS negotiation' (RFC4642)
+	 */
+	ResponseCodeTLSFailure ResponseCode = 580
+
+	// Compression
+
+	/**
+	 * 'Compression active' (RFC8054)
+	 */
+	ResponseCodeCompressionActive ResponseCode = 206
+
 	// Misc
 
 	/**
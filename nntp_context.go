@@ -0,0 +1,426 @@
+package nntp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Returns a view of the connection whose commands are bound to ctx. The view shares the session with conn, so that
+// state changes such as the selected group or a TLS upgrade are visible through both.
+//
+// Deadlines of ctx are applied to the underlying network connection while a command runs, and cancelling ctx aborts any
+// blocked read or write. A command interrupted this way leaves the connection in an unknown protocol state, so the
+// connection is reported unusable by Err and every later command fails with ErrorConnUnusable. Article bodies returned
+// by ARTICLE, BODY and HELP are bound to ctx as well while they are being read.
+func (conn *Conn) WithContext(ctx context.Context) *Conn {
+	if ctx == nil {
+		panic("nil context")
+	}
+	return &Conn{conn.session, ctx}
+}
+
+// Returns the context commands issued through this view are bound to. It defaults to context.Background.
+func (conn *Conn) Context() context.Context {
+	return conn.ctx
+}
+
+// Returns the reason the connection is unusable, or nil if it is still in sync with the server. An unusable connection
+// should be closed.
+func (conn *Conn) Err() error {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	return conn.broken
+}
+
+// Marks the connection as unusable.
+func (conn *Conn) kill(cause error) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if conn.broken == nil {
+		conn.broken = fmt.Errorf("%w: %v", ErrorConnUnusable, cause)
+	}
+}
+
+type deadliner interface {
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+}
+
+// A point in time in the past, used to unblock pending I/O.
+var aLongTimeAgo = time.Unix(1, 0)
+
+// The directions of the transport bound to a context, see bind.
+const (
+	directionRead = 1 << iota
+	directionWrite
+)
+
+// Binds the given directions of the transport to the context of the view: its deadline is applied, and pending I/O is
+// aborted once it is done. The returned function releases the context and returns its error if I/O was aborted.
+func (conn *Conn) bind(directions int) (release func() error) {
+	ctx := conn.ctx
+	if ctx.Done() == nil {
+		return func() error { return nil }
+	}
+	conn.mu.Lock()
+	netconn := conn.netconn
+	conn.mu.Unlock()
+	d, _ := netconn.(deadliner)
+	setDeadline := func(t time.Time) {
+		if directions&directionRead != 0 {
+			d.SetReadDeadline(t)
+		}
+		if directions&directionWrite != 0 {
+			d.SetWriteDeadline(t)
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok && d != nil {
+		setDeadline(deadline)
+	}
+	stop, stopped := make(chan struct{}), make(chan bool)
+	go func() {
+		select {
+		case <-ctx.Done():
+			if d != nil {
+				setDeadline(aLongTimeAgo)
+			} else {
+				// no other way to unblock pending I/O
+				conn.kill(ctx.Err())
+				netconn.Close()
+			}
+			stopped <- true
+		case <-stop:
+			stopped <- false
+		}
+	}()
+	return func() (cause error) {
+		close(stop)
+		if <-stopped {
+			cause = ctx.Err()
+		} else if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+			// the transport deadline may expire before the context notices
+			cause = context.DeadlineExceeded
+		}
+		if d != nil {
+			setDeadline(time.Time{})
+		}
+		return
+	}
+}
+
+// Waits for the turn id, giving up when the context of the view is done. A turn given up is still taken in order by a
+// goroutine, which runs skip to pass it on.
+func (conn *Conn) await(start func(uint), id uint, skip func()) (err error) {
+	done := conn.ctx.Done()
+	if done == nil {
+		start(id)
+		return
+	}
+	acquired := make(chan struct{})
+	go func() {
+		start(id)
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+	case <-done:
+		go func() {
+			<-acquired
+			skip()
+		}()
+		err = conn.ctx.Err()
+	}
+	return
+}
+
+// A command in flight. Commands take a number in the pipeline of the session when they begin, send their requests in
+// that order, and read their responses in the same order, so that concurrent callers share the connection safely.
+type call struct {
+	conn    *Conn
+	name    string
+	id      uint
+	release func() error // releases the context bound to the current phase
+	sent    bool         // the request turn was passed on
+	reading bool         // the response turn is held
+	body    *pendingBody // the body holding the response turn once the command ends
+}
+
+// Begins a command holding the connection exclusively, for commands made of several requests or changing the session
+// state. It fails without any I/O if the connection is already unusable or the context is done. The returned function
+// must be deferred with the command error, it passes the connection on and marks it unusable when the command failed
+// in a way leaving it out of sync.
+func (conn *Conn) begin(name string) (end func(*error), err error) {
+	c, err := conn.acquire(name)
+	if err != nil {
+		return
+	}
+	if err = conn.await(conn.pipeline.StartResponse, c.id, func() { conn.pipeline.EndResponse(c.id) }); err != nil {
+		err = fmt.Errorf("[nntp.%s] %w", name, err)
+		c.sent = true
+		conn.pipeline.EndRequest(c.id)
+		return
+	}
+	c.reading = true
+	c.release = conn.bind(directionRead | directionWrite)
+	end = c.end
+	if err = conn.Err(); err != nil {
+		err = fmt.Errorf("[nntp.%s] %w", name, err)
+		end(&err)
+		end = nil
+	}
+	return
+}
+
+// Begins a command made of a single request, which is pipelined with the commands of other callers: the request is
+// sent as soon as the previous requests are, and call.flush waits for the previous responses before reading its own.
+func (conn *Conn) beginRequest(name string) (c *call, err error) {
+	if c, err = conn.acquire(name); err != nil {
+		return
+	}
+	c.release = conn.bind(directionWrite)
+	return
+}
+
+// Takes a number in the pipeline and waits for the turn to send the request.
+func (conn *Conn) acquire(name string) (c *call, err error) {
+	if err = conn.Err(); err == nil {
+		err = conn.ctx.Err()
+	}
+	if err != nil {
+		err = fmt.Errorf("[nntp.%s] %w", name, err)
+		return
+	}
+	conn.drainBody()
+	c = &call{conn: conn, name: name, id: conn.pipeline.Next()}
+	skip := func() {
+		conn.pipeline.EndRequest(c.id)
+		conn.pipeline.StartResponse(c.id)
+		conn.pipeline.EndResponse(c.id)
+	}
+	if err = conn.await(conn.pipeline.StartRequest, c.id, skip); err != nil {
+		err = fmt.Errorf("[nntp.%s] %w", name, err)
+		c = nil
+		return
+	}
+	// a command before this one may have left the connection unusable while it was waiting
+	if err = conn.Err(); err != nil {
+		err = fmt.Errorf("[nntp.%s] %w", name, err)
+		skip()
+		c = nil
+	}
+	return
+}
+
+// Passes the request turn on once the request is sent, and waits for the turn to read the response.
+func (c *call) flush() (err error) {
+	conn := c.conn
+	c.release()
+	c.sent = true
+	conn.pipeline.EndRequest(c.id)
+	if err = conn.await(conn.pipeline.StartResponse, c.id, func() { conn.pipeline.EndResponse(c.id) }); err != nil {
+		// nobody is left to read the response of the request already sent
+		err = fmt.Errorf("[nntp.%s] %w", c.name, err)
+		conn.kill(err)
+		c.release = nil
+		return
+	}
+	c.reading = true
+	c.release = conn.bind(directionRead)
+	// a previous response may have left the connection out of sync while this command was waiting
+	if err = conn.Err(); err != nil {
+		err = fmt.Errorf("[nntp.%s] %w", c.name, err)
+	}
+	return
+}
+
+// Ends the command with its error, see begin.
+func (c *call) end(err *error) {
+	conn := c.conn
+	if c.body != nil && *err == nil {
+		// the body keeps the context bound until it is read
+		c.body.release, c.release = c.release, nil
+	}
+	if c.release != nil {
+		if cause := c.release(); cause != nil && *err != nil {
+			*err = fmt.Errorf("%v: %w", *err, cause)
+			conn.kill(cause)
+		}
+	}
+	if *err != nil && !inSync(*err) {
+		conn.kill(*err)
+	}
+	if !c.sent {
+		conn.pipeline.EndRequest(c.id)
+	}
+	switch {
+	case c.body != nil && *err == nil:
+		// the body passes the response turn on once it is read
+		conn.mu.Lock()
+		conn.body = c.body
+		conn.mu.Unlock()
+	case c.reading:
+		conn.pipeline.EndResponse(c.id)
+	default:
+		go func() {
+			conn.pipeline.StartResponse(c.id)
+			conn.pipeline.EndResponse(c.id)
+		}()
+	}
+}
+
+// Reports whether the connection is still in sync with the server after a command failed with err, that is, the error
+// was either detected before anything was sent, the server replied with a single line error response, or the command
+// brought the server back in sync, see syncedError.
+func inSync(err error) bool {
+	var response *Error
+	var synced syncedError
+	return errors.As(err, &response) ||
+		errors.As(err, &synced) ||
+		errors.Is(err, ErrorInvalidParams) ||
+		errors.Is(err, ErrorInvalidMessageID) ||
+		errors.Is(err, ErrorCapabilityMissing) ||
+		errors.Is(err, ErrorTLSRequired) ||
+		errors.Is(err, ErrorNoGroupSelected)
+}
+
+// An error after which the command brought the server back in sync, e.g. by cancelling a SASL exchange.
+type syncedError struct {
+	error
+}
+
+func (err syncedError) Unwrap() error {
+	return err.error
+}
+
+// Returns the body of the response, read from the connection through the view of the command. The command keeps its
+// response turn until the body is read to EOF or closed, or until the next command is issued: a command of the same
+// view drains what is left of the body, while a command of another view reads it in memory for its reader.
+func (c *call) bodyReader(r io.Reader) io.Reader {
+	c.body = &pendingBody{call: c, r: r}
+	return c.body
+}
+
+// Passes the response turn held by the body left pending by a previous command on. The body is drained if it was
+// returned through this view, and read in memory otherwise, since views such as the ones of WithContext may be used
+// by the same caller as well as by concurrent ones, and waiting for the body to be read could wait forever.
+func (conn *Conn) drainBody() {
+	conn.mu.Lock()
+	body := conn.body
+	conn.mu.Unlock()
+	if body == nil {
+		return
+	}
+	if body.call.conn == conn {
+		body.Close()
+	} else {
+		body.buffer()
+	}
+}
+
+// Reports whether an article body returned by a command of any view is still to be read.
+func (conn *Conn) hasPendingBody() bool {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	return conn.body != nil
+}
+
+// An article body holding the response turn of its command.
+type pendingBody struct {
+	call    *call
+	mu      sync.Mutex
+	r       io.Reader
+	release func() error // releases the context bound to the response, see call.flush
+	done    bool
+
+	// What was left of the body when it was read in memory by a command of another view, see buffer, and the error
+	// that ended the reading.
+	rest *bytes.Reader
+	err  error
+}
+
+func (b *pendingBody) Read(p []byte) (n int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rest != nil {
+		if n, err = b.rest.Read(p); err == io.EOF && b.err != nil {
+			err = b.err
+		}
+		return
+	}
+	return b.read(p)
+}
+
+func (b *pendingBody) read(p []byte) (n int, err error) {
+	if b.done {
+		return 0, io.EOF
+	}
+	conn := b.call.conn
+	if err = conn.Err(); err == nil {
+		err = conn.ctx.Err()
+	}
+	if err != nil {
+		err = b.finish(fmt.Errorf("[nntp.%s] %w", b.call.name, err))
+		return
+	}
+	if n, err = b.r.Read(p); err != nil {
+		err = b.finish(err)
+	}
+	return
+}
+
+// Drains what is left of the body.
+func (b *pendingBody) Close() (err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rest, b.err = nil, nil
+	buf := make([]byte, 4096)
+	for !b.done {
+		if _, err = b.read(buf); err == io.EOF {
+			err = nil
+		}
+	}
+	return
+}
+
+// Reads what is left of the body in memory, for its reader to read it later on.
+func (b *pendingBody) buffer() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done {
+		return
+	}
+	var rest bytes.Buffer
+	buf := make([]byte, 4096)
+	for !b.done {
+		n, err := b.read(buf)
+		rest.Write(buf[:n])
+		if err != nil && err != io.EOF {
+			b.err = err
+		}
+	}
+	b.rest = bytes.NewReader(rest.Bytes())
+}
+
+// Ends the body with the error that stopped its reading, releasing its context and passing the response turn on.
+func (b *pendingBody) finish(err error) error {
+	b.done = true
+	conn := b.call.conn
+	if cause := b.release(); cause != nil && err != io.EOF {
+		err = fmt.Errorf("[nntp.%s] %v: %w", b.call.name, err, cause)
+	}
+	if err != io.EOF {
+		conn.kill(err)
+	}
+	conn.mu.Lock()
+	if conn.body == b {
+		conn.body = nil
+	}
+	conn.mu.Unlock()
+	conn.pipeline.EndResponse(b.call.id)
+	return err
+}
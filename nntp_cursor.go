@@ -0,0 +1,121 @@
+package nntp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/textproto.v0"
+)
+
+// A Cursor moves back and forth over the articles of the currently selected group with the NEXT and LAST commands, and
+// fetches the header or body of the article it points to only when asked for. It moves the current article number of
+// the session along with it, so it should not be mixed with other commands changing the current article on the same
+// connection.
+//
+//	cursor := conn.NewCursor()
+//	for cursor.Next() {
+//		header, err := cursor.Head()
+//		...
+//	}
+//	if err := cursor.Err(); err != nil {
+//		...
+//	}
+//
+// A Cursor is not safe for concurrent use.
+type Cursor struct {
+	conn    *Conn
+	article *Article // the article pointed to, nil until the cursor first moves
+	err     error
+}
+
+// Returns a cursor over the currently selected group. The cursor points to no article until it first moves.
+func (conn *Conn) NewCursor() *Cursor {
+	return &Cursor{conn: conn}
+}
+
+// Moves to the next article in the group and reports whether there is one. The first move of a new cursor selects
+// the current article of the session instead, which is the first article of the group right after GROUP or LISTGROUP.
+// It returns false at the end of the group or on failure, Err telling them apart.
+func (c *Cursor) Next() bool {
+	if c.article == nil {
+		return c.move(c.conn.CmdStat())
+	}
+	return c.move(c.conn.CmdNext())
+}
+
+// Moves to the previous article in the group and reports whether there is one. The first move of a new cursor selects
+// the current article of the session, see Next. It returns false at the start of the group or on failure, Err telling
+// them apart.
+func (c *Cursor) Prev() bool {
+	if c.article == nil {
+		return c.move(c.conn.CmdStat())
+	}
+	return c.move(c.conn.CmdLast())
+}
+
+// Moves to the article with the given number in the group, e.g. the Last one of GroupStat to walk the group backwards
+// with Prev. It returns false if there is no such article or on failure, Err telling them apart.
+func (c *Cursor) Select(articleNumber int64) bool {
+	return c.move(c.conn.CmdStat(ArticleNumber(articleNumber)))
+}
+
+func (c *Cursor) move(article *Article, err error) bool {
+	if err != nil {
+		// running off either end of the group, or onto a missing article, leaves the cursor where it was
+		if !errors.Is(err, ResponseCodeNoNextArticle) && !errors.Is(err, ResponseCodeNoPreviousArticle) &&
+			!errors.Is(err, ResponseCodeNoArticleSelected) && !errors.Is(err, ResponseCodeNoSuchArticleNumber) {
+			c.err = err
+		}
+		return false
+	}
+	c.article, c.err = article, nil
+	return true
+}
+
+// Returns the article the cursor points to, carrying its number and message-id along with its header once fetched
+// with Head. Returns nil until the cursor first moves.
+func (c *Cursor) Article() *Article {
+	if c.article == nil {
+		return nil
+	}
+	article := *c.article
+	return &article
+}
+
+// Returns the header of the article the cursor points to, fetching it with the HEAD command on first use.
+func (c *Cursor) Head() (header textproto.MIMEHeader, err error) {
+	if c.article == nil {
+		err = fmt.Errorf("[nntp.Cursor.Head] %w", ResponseCodeNoArticleSelected)
+		return
+	}
+	if c.article.Header == nil {
+		var article *Article
+		if article, err = c.conn.CmdHead(ArticleNumber(c.article.ArticleNumber)); err != nil {
+			return
+		}
+		c.article.Header = article.Header
+	}
+	header = c.article.Header
+	return
+}
+
+// Returns the body of the article the cursor points to, fetched with the BODY command on every call. The body is read
+// from the connection and must be consumed before the cursor moves again, see Article.Body.
+func (c *Cursor) Body() (body io.Reader, err error) {
+	if c.article == nil {
+		err = fmt.Errorf("[nntp.Cursor.Body] %w", ResponseCodeNoArticleSelected)
+		return
+	}
+	article, err := c.conn.CmdBody(ArticleNumber(c.article.ArticleNumber))
+	if err != nil {
+		return
+	}
+	body = article.Body
+	return
+}
+
+// Returns the failure that stopped the last move, or nil if it stopped at an end of the group.
+func (c *Cursor) Err() error {
+	return c.err
+}
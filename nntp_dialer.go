@@ -3,6 +3,7 @@ package nntp
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
 	"net"
 )
 
@@ -14,20 +15,32 @@ type Dialer struct {
 	// Config is the TLS configuration to use for new TLS connections. A nil configuration is equivalent to the zero
 	// configuration; see the documentation of tls.Config for the defaults.
 	Config *tls.Config
+
+	// RequireStartTLS makes Dial upgrade plaintext connections with STARTTLS right after the welcome message. Dial fails
+	// if the server does not offer STARTTLS, and the returned connection refuses to send AUTHINFO credentials in the
+	// clear.
+	RequireStartTLS bool
 }
 
 func (d *Dialer) Dial(ctx context.Context, network, addr string) (conn *Conn, err error) {
-	if d.NetDialer == nil {
-		d.NetDialer = &net.Dialer{}
+	dialer := d.NetDialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
 	}
-	netconn, err := d.NetDialer.DialContext(ctx, network, addr)
+	netconn, err := dialer.DialContext(ctx, network, addr)
 	if err != nil {
 		return
 	}
 	c := NewConn(netconn)
-	if err = c.ReadWelcome(); err != nil {
+	if err = c.WithContext(ctx).ReadWelcome(); err != nil {
 		c.Close()
 	}
+	if err == nil && d.RequireStartTLS {
+		c.requireTLS = true
+		if err = d.startTLS(c.WithContext(ctx), addr); err != nil {
+			c.Close()
+		}
+	}
 	conn = c
 	return
 }
@@ -42,9 +55,35 @@ func (d *Dialer) DialTLS(ctx context.Context, network, addr string) (conn *Conn,
 		return
 	}
 	c := NewConn(netconn)
-	if err = c.ReadWelcome(); err != nil {
+	if err = c.WithContext(ctx).ReadWelcome(); err != nil {
 		c.Close()
 	}
 	conn = c
 	return
 }
+
+func (d *Dialer) startTLS(conn *Conn, addr string) (err error) {
+	capabilities, err := conn.Capabilities()
+	if err != nil {
+		return
+	}
+	if !capabilities.StartTLS {
+		err = fmt.Errorf("[nntp.Dial] server does not offer STARTTLS: %w", ErrorTLSRequired)
+		return
+	}
+	config := d.Config
+	if config == nil || config.ServerName == "" {
+		// same as tls.Dialer, verify the certificate against the dialed host name
+		if config == nil {
+			config = &tls.Config{}
+		} else {
+			config = config.Clone()
+		}
+		if host, _, e := net.SplitHostPort(addr); e == nil {
+			config.ServerName = host
+		} else {
+			config.ServerName = addr
+		}
+	}
+	return conn.StartTLS(config)
+}
@@ -21,3 +21,9 @@ func (err Error) Unwrap() error {
 var ErrorInvalidParams = errors.New("invalid parameters")
 var ErrorInvalidMessageID = errors.New("invalid message-id format")
 var ErrorParsingResponse = errors.New("cannot parse response")
+var ErrorTLSRequired = errors.New("TLS is required")
+var ErrorCapabilityMissing = errors.New("capability not advertised by server")
+var ErrorSASLUnexpectedChallenge = errors.New("unexpected SASL challenge")
+var ErrorConnUnusable = errors.New("connection is in an unknown protocol state")
+var ErrorPoolClosed = errors.New("pool closed")
+var ErrorNoGroupSelected = errors.New("no newsgroup selected")
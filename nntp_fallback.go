@@ -0,0 +1,117 @@
+package nntp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/option.v0"
+	"gopkg.in/rx.v0"
+)
+
+// Fetches the overviews of the articles with OVER or XOVER, whichever the server supports. The command advertised in
+// the capabilities is tried first, XOVER being preferred on servers predating RFC 3977 that do not know CAPABILITIES.
+// A command answered with 500 is remembered as unknown for the life of the connection and the other one is used
+// instead. Articles selected by message-id require the OVER MSGID capability, since XOVER does not accept them.
+func (conn *Conn) Overview(options ...OverOption) rx.Observable[*ArticleOverview] {
+	return rx.Defer(func(rx.Lifecycle) rx.Observable[*ArticleOverview] {
+		capabilities, err := conn.negotiatedCapabilities()
+		if err != nil {
+			return rx.Error[*ArticleOverview](fmt.Errorf("[nntp.Overview] %w", err))
+		}
+		commands := map[string]func(...OverOption) rx.Observable[*ArticleOverview]{
+			"OVER":  conn.CmdOver,
+			"XOVER": conn.CmdXOver,
+		}
+		verbs := []string{"XOVER", "OVER"}
+		if option.New(options).messageID != "" {
+			if capabilities == nil || !capabilities.OverMessageID {
+				return rx.Error[*ArticleOverview](fmt.Errorf("[nntp.Overview] OVER MSGID: %w", ErrorCapabilityMissing))
+			}
+			verbs = []string{"OVER"}
+		} else if capabilities != nil && capabilities.Over {
+			verbs = []string{"OVER", "XOVER"}
+		}
+		return fallback(conn, "Overview", verbs, func(verb string) rx.Observable[*ArticleOverview] {
+			return commands[verb](options...)
+		})
+	})
+}
+
+// Fetches the value of a header field of the articles with HDR or XHDR, whichever the server supports, see Overview.
+func (conn *Conn) Headers(field string, options ...OverOption) rx.Observable[*ArticleHeader] {
+	return rx.Defer(func(rx.Lifecycle) rx.Observable[*ArticleHeader] {
+		capabilities, err := conn.negotiatedCapabilities()
+		if err != nil {
+			return rx.Error[*ArticleHeader](fmt.Errorf("[nntp.Headers] %w", err))
+		}
+		commands := map[string]func(string, ...OverOption) rx.Observable[*ArticleHeader]{
+			"HDR":  conn.CmdHdr,
+			"XHDR": conn.CmdXHdr,
+		}
+		verbs := []string{"XHDR", "HDR"}
+		if capabilities != nil && capabilities.Hdr {
+			verbs = []string{"HDR", "XHDR"}
+		}
+		return fallback(conn, "Headers", verbs, func(verb string) rx.Observable[*ArticleHeader] {
+			return commands[verb](field, options...)
+		})
+	})
+}
+
+// Returns the capabilities of the server, or nil for servers predating RFC 3977 that answer CAPABILITIES with 500.
+func (conn *Conn) negotiatedCapabilities() (capabilities *Capabilities, err error) {
+	if conn.isUnknownCommand("CAPABILITIES") {
+		return
+	}
+	if capabilities, err = conn.Capabilities(); errors.Is(err, ResponseCodeUnknownCommand) {
+		conn.setUnknownCommand("CAPABILITIES")
+		err = nil
+	}
+	return
+}
+
+// Issues the commands built by cmd for each verb in turn, until one is not answered with 500.
+func fallback[T any](conn *Conn, name string, verbs []string, cmd func(verb string) rx.Observable[T]) rx.Observable[T] {
+	return rx.Func(func(subscriber rx.Writer[T]) (err error) {
+		for _, verb := range verbs {
+			if conn.isUnknownCommand(verb) {
+				continue
+			}
+			writer, reader := rx.Pipe[T](subscriber, rx.PipeWithDiscardChildError())
+			cmd(verb).Subscribe(writer)
+			for {
+				item, ok := reader.Read()
+				if !ok {
+					break
+				}
+				if !subscriber.Write(item) {
+					return
+				}
+			}
+			if err = reader.Wait(); !errors.Is(err, ResponseCodeUnknownCommand) {
+				return
+			}
+			conn.setUnknownCommand(verb)
+		}
+		err = fmt.Errorf("[nntp.%s] none of %s is known to the server: %w", name, strings.Join(verbs, " or "),
+			ErrorCapabilityMissing)
+		return
+	})
+}
+
+func (conn *Conn) isUnknownCommand(verb string) bool {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	return conn.unknownCommands[verb]
+}
+
+// Remembers that the server answered the command with 500, so that it is not tried again on the connection.
+func (conn *Conn) setUnknownCommand(verb string) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if conn.unknownCommands == nil {
+		conn.unknownCommands = map[string]bool{}
+	}
+	conn.unknownCommands[verb] = true
+}
@@ -0,0 +1,260 @@
+package nntp
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"gopkg.in/option.v0"
+	"gopkg.in/rx.v0"
+	"gopkg.in/textproto.v0"
+)
+
+// A feedEntry is an article offered to the server, queued in the order its command was sent.
+type feedEntry struct {
+	result   *FeedResult
+	load     func() (*Article, error)
+	takeThis bool
+
+	// Closed once the result is complete, see streamFeed.
+	done chan struct{}
+}
+
+// Offers the articles to a peer in streaming mode, see CmdModeStream. Each article is first offered with CHECK, and
+// sent with TAKETHIS only if the server wants it. Commands are pipelined, up to the window set by WithFeedWindow, and
+// one result is emitted for each article in the order of the source.
+func (conn *Conn) CmdStreamFeed(articles rx.Observable[*Article], options ...FeedOption) rx.Observable[*FeedResult] {
+	return conn.streamFeed("CmdStreamFeed", func(subscriber rx.Writer[*feedEntry]) {
+		writer, reader := rx.Pipe[*Article](subscriber)
+		subscriber.Go(func() (err error) {
+			for {
+				article, ok := reader.Read()
+				if !ok {
+					return
+				}
+				id := article.MessageID
+				if id == "" && article.Header != nil {
+					id = MessageID(article.Header.Get("Message-Id"))
+				}
+				entry := &feedEntry{result: &FeedResult{MessageID: id.Full()}, load: func() (*Article, error) {
+					return article, nil
+				}}
+				if !subscriber.Write(entry) {
+					return
+				}
+			}
+		})
+		articles.Subscribe(writer)
+	}, options)
+}
+
+// Same as CmdStreamFeed, but offers articles by message-id and only loads the ones the server wants.
+func (conn *Conn) CmdStreamFeedLazy(messageIDs rx.Observable[MessageID], loader func(MessageID) (*Article, error), options ...FeedOption) rx.Observable[*FeedResult] {
+	return conn.streamFeed("CmdStreamFeedLazy", func(subscriber rx.Writer[*feedEntry]) {
+		writer, reader := rx.Pipe[MessageID](subscriber)
+		subscriber.Go(func() (err error) {
+			for {
+				id, ok := reader.Read()
+				if !ok {
+					return
+				}
+				entry := &feedEntry{result: &FeedResult{MessageID: id.Full()}, load: func() (*Article, error) {
+					return loader(id)
+				}}
+				if !subscriber.Write(entry) {
+					return
+				}
+			}
+		})
+		messageIDs.Subscribe(writer)
+	}, options)
+}
+
+func (conn *Conn) streamFeed(name string, source func(rx.Writer[*feedEntry]), options []FeedOption) rx.Observable[*FeedResult] {
+	opts := option.New(options, WithFeedWindow(16))
+	return rx.Func(func(subscriber rx.Writer[*FeedResult]) (err error) {
+		if opts.window < 1 {
+			err = fmt.Errorf("[nntp.%s] window must be positive: %w", name, ErrorInvalidParams)
+			return
+		}
+		end, err := conn.begin(name)
+		if err != nil {
+			return
+		}
+		var (
+			mu      sync.Mutex // serializes whole commands between the writer and the reader
+			stopped bool       // set under mu when the reader stops, no command may be sent afterwards
+		)
+		slots := make(chan struct{}, opts.window)
+		sent := make(chan *feedEntry, opts.window)
+		// the entries in the order of the source, accepted articles being queued again in sent behind the CHECK
+		// commands sent after theirs
+		ordered := make(chan *feedEntry, opts.window)
+		writerDone := make(chan struct{})
+
+		entryWriter, entryReader := rx.Pipe[*feedEntry](subscriber)
+		subscriber.Go(func() (err error) {
+			defer close(writerDone)
+			for {
+				entry, ok := entryReader.Read()
+				if !ok {
+					return
+				}
+				select {
+				case slots <- struct{}{}:
+				case <-subscriber.Dying():
+					return
+				}
+				mu.Lock()
+				if stopped {
+					mu.Unlock()
+					return
+				}
+				entry.done = make(chan struct{})
+				if entry.result.Err = entry.result.MessageID.ValidateFull(); entry.result.Err != nil {
+					entry.result.Err = fmt.Errorf("[nntp.%s] %w: %v", name, ErrorInvalidMessageID, entry.result.Err)
+				} else if err = conn.PrintfLine("CHECK %s", entry.result.MessageID); err != nil {
+					err = fmt.Errorf("[nntp.%s] failed to send CHECK command: %w", name, err)
+					conn.kill(err)
+					mu.Unlock()
+					return
+				}
+				sent <- entry
+				ordered <- entry
+				mu.Unlock()
+			}
+		})
+		subscriber.Go(func() (err error) {
+			defer end(&err)
+			defer func() {
+				mu.Lock()
+				stopped = true
+				if len(sent) > 0 {
+					conn.kill(fmt.Errorf("%d streaming responses left unread", len(sent)))
+				}
+				mu.Unlock()
+			}()
+			for {
+				var entry *feedEntry
+				select {
+				case entry = <-sent:
+				case <-writerDone:
+					select {
+					case entry = <-sent:
+					default:
+						return
+					}
+				case <-subscriber.Dying():
+					return
+				}
+				result := entry.result
+				if result.Err == nil {
+					var code int
+					var msg string
+					if code, msg, err = conn.ReadCodeLine(0); err != nil {
+						err = fmt.Errorf("[nntp.%s] failed to read response for %s: %w", name, result.MessageID, err)
+						return
+					}
+					if id, _, _ := strings.Cut(msg, " "); MessageID(id) != result.MessageID {
+						err = fmt.Errorf("[nntp.%s] response %d %#v does not match %s: %w", name, code, msg, result.MessageID, ErrorParsingResponse)
+						return
+					}
+					switch ResponseCode(code) {
+					case ResponseCodeCheckSend: // 238
+						result.Check = ResponseCode(code)
+						var article *Article
+						if article, result.Err = entry.load(); result.Err == nil {
+							// the slot of the CHECK command is handed over to the TAKETHIS command
+							entry.takeThis = true
+							mu.Lock()
+							if err = conn.writeTakeThis(result.MessageID, article); err != nil {
+								err = fmt.Errorf("[nntp.%s] failed to send TAKETHIS command: %w", name, err)
+								mu.Unlock()
+								return
+							}
+							sent <- entry
+							mu.Unlock()
+							continue
+						}
+						result.Err = fmt.Errorf("[nntp.%s] failed to load article %s: %w", name, result.MessageID, result.Err)
+					case ResponseCodeCheckLater, ResponseCodeCheckUnwanted: // 431 || 438
+						if entry.takeThis {
+							err = fmt.Errorf("[nntp.%s] unexpected TAKETHIS response: %w", name, &Error{ResponseCode(code), msg})
+							return
+						}
+						result.Check = ResponseCode(code)
+					case ResponseCodeTakeThisSuccess, ResponseCodeTakeThisRejected: // 239 || 439
+						if !entry.takeThis {
+							err = fmt.Errorf("[nntp.%s] unexpected CHECK response: %w", name, &Error{ResponseCode(code), msg})
+							return
+						}
+						result.TakeThis = ResponseCode(code)
+					default:
+						err = fmt.Errorf("[nntp.%s] unexpected response: %w", name, &Error{ResponseCode(code), msg})
+						return
+					}
+				}
+				close(entry.done)
+			}
+		})
+		subscriber.Go(func() (err error) {
+			for {
+				var entry *feedEntry
+				select {
+				case entry = <-ordered:
+				case <-writerDone:
+					select {
+					case entry = <-ordered:
+					default:
+						return
+					}
+				case <-subscriber.Dying():
+					return
+				}
+				select {
+				case <-entry.done:
+				case <-subscriber.Dying():
+					return
+				}
+				<-slots
+				if !subscriber.Write(entry.result) {
+					return
+				}
+			}
+		})
+		source(entryWriter)
+		return
+	})
+}
+
+// Sends the TAKETHIS command immediately followed by the article, without waiting for a response.
+func (conn *Conn) writeTakeThis(id MessageID, article *Article) (err error) {
+	if err = conn.PrintfLine("TAKETHIS %s", id); err != nil {
+		return
+	}
+	for key, values := range article.Header {
+		key = textproto.CanonicalMIMEHeaderKey(key)
+		if key == "Message-Id" {
+			continue
+		}
+		for _, value := range values {
+			if err = conn.PrintfLine("%s: %s", key, value); err != nil {
+				return
+			}
+		}
+	}
+	if err = conn.PrintfLine("Message-Id: %s", id); err != nil {
+		return
+	}
+	if err = conn.PrintfLine(""); err != nil {
+		return
+	}
+	writer := conn.DotWriter()
+	if article.Body != nil {
+		if _, err = io.Copy(writer, article.Body); err != nil {
+			return
+		}
+	}
+	return writer.Close()
+}
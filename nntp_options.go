@@ -13,7 +13,7 @@ func GroupName(group string) GroupOption {
 	}
 }
 
-func GroupRange(firstNum, lastNum int) GroupOption {
+func GroupRange(firstNum, lastNum int64) GroupOption {
 	return func(o *groupOptions) {
 		o.groupRange = &Range{firstNum, lastNum}
 	}
@@ -23,12 +23,12 @@ type ArticleOption func(*articleOptions)
 
 type articleOptions struct {
 	messageID      MessageID
-	articleNumber  int
+	articleNumber  int64
 	dotEncodedBody bool
 }
 
 // Article number in a newsgroup. The lowest article number is 1. Number 0 is only used for special meanings.
-func ArticleNumber(articleNumber int) ArticleOption {
+func ArticleNumber(articleNumber int64) ArticleOption {
 	return func(o *articleOptions) {
 		o.articleNumber = articleNumber
 	}
@@ -49,11 +49,14 @@ func WithDotEncodedBody() ArticleOption {
 	}
 }
 
+// Selects the articles for the OVER/XOVER and HDR/XHDR commands.
 type OverOption func(*overOptions)
 
 type overOptions struct {
-	messageID    MessageID
-	articleRange *Range
+	messageID      MessageID
+	articleRange   *Range
+	articleSet     *ArticleSet
+	overviewFormat []OverviewFieldFormat
 }
 
 func OverMessageID(messageID MessageID) OverOption {
@@ -62,8 +65,98 @@ func OverMessageID(messageID MessageID) OverOption {
 	}
 }
 
-func WithArticleRange(firstNum, lastNum int) OverOption {
+func WithArticleRange(firstNum, lastNum int64) OverOption {
 	return func(o *overOptions) {
 		o.articleRange = &Range{firstNum, lastNum}
 	}
 }
+
+// Names the fields of the overview lines after the format, as returned by CmdListOverviewFmt, instead of the format
+// cached by the last CmdListOverviewFmt on the connection.
+func WithOverviewFormat(format []OverviewFieldFormat) OverOption {
+	return func(o *overOptions) {
+		o.overviewFormat = format
+	}
+}
+
+// Selects the articles of the set, one command being issued for each of its ranges in ascending order. Ranges holding
+// no article anymore are skipped.
+func WithArticleSet(set *ArticleSet) OverOption {
+	return func(o *overOptions) {
+		o.articleSet = set
+	}
+}
+
+type FeedOption func(*feedOptions)
+
+type feedOptions struct {
+	window int
+}
+
+// Maximum number of CHECK and TAKETHIS commands waiting for a response. Defaults to 16.
+func WithFeedWindow(window int) FeedOption {
+	return func(o *feedOptions) {
+		o.window = window
+	}
+}
+
+type StreamOption func(*streamOptions)
+
+type streamOptions struct {
+	window   int
+	buffered bool
+}
+
+// Maximum number of pipelined ARTICLE, HEAD, BODY or STAT commands waiting for a response. Defaults to 64.
+func WithStreamWindow(window int) StreamOption {
+	return func(o *streamOptions) {
+		o.window = window
+	}
+}
+
+// Read each body in full before emitting its article, instead of letting the consumer read it from the connection.
+// Responses are then read ahead of the consumer, at the cost of holding whole bodies in memory.
+func WithBufferedBodies() StreamOption {
+	return func(o *streamOptions) {
+		o.buffered = true
+	}
+}
+
+// Tunes the parallel overview download of Pool.Overview.
+type ParallelOption func(*parallelOptions)
+
+type parallelOptions struct {
+	conns       int
+	chunkSize   int64
+	attempts    int
+	overOptions []OverOption
+}
+
+// Number of connections of the pool fetching chunks at the same time. Defaults to 4.
+func WithParallelConns(conns int) ParallelOption {
+	return func(o *parallelOptions) {
+		o.conns = conns
+	}
+}
+
+// Number of article numbers fetched with each OVER or XOVER command. Defaults to 10000.
+func WithChunkSize(size int64) ParallelOption {
+	return func(o *parallelOptions) {
+		o.chunkSize = size
+	}
+}
+
+// Number of times a chunk is tried, each time on another connection, before the download fails. Defaults to 3.
+func WithChunkAttempts(attempts int) ParallelOption {
+	return func(o *parallelOptions) {
+		o.attempts = attempts
+	}
+}
+
+// Options of the OVER or XOVER commands that do not select articles, such as WithOverviewFormat. The articles are
+// selected by the chunks.
+func WithOverOptions(options ...OverOption) ParallelOption {
+	return func(o *parallelOptions) {
+		o.overOptions = options
+	}
+}
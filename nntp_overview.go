@@ -0,0 +1,214 @@
+package nntp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// An OverviewDecoder reads the lines of an OVER or XOVER response body one at a time, for bulk header synchronization.
+// Lines may be of any length. Once the decoder has warmed up, neither moving to the next line with Next nor decoding it
+// into a reused ArticleOverview, e.g. from GetArticleOverview, allocates: the fields of the current line can be read
+// in place with Field, and Decode fills the overview with strings sharing the memory of the line.
+//
+//	for decoder.Next() {
+//		if err := decoder.Decode(overview); err != nil {
+//			...
+//		}
+//	}
+//	if err := decoder.Err(); err != nil {
+//		...
+//	}
+//
+// An OverviewDecoder is not safe for concurrent use.
+type OverviewDecoder struct {
+	src     io.Reader
+	r       *bufio.Reader
+	buf     []byte   // holds the lines longer than the buffer of r
+	line    []byte   // the current line, without its line terminator
+	offsets [][2]int // the bounds of the fields of the current line
+	format  []OverviewFieldFormat
+	err     error
+}
+
+// Returns a decoder of the overview lines read from r, typically a DotReader of the connection. The extra fields are
+// named after the format if not nil, see ArticleOverview.Extra.
+func NewOverviewDecoder(r io.Reader, format []OverviewFieldFormat) *OverviewDecoder {
+	// the reader is wrapped to hide any WriteTo method, textproto's one does not stop at the end of the body
+	return &OverviewDecoder{src: r, r: bufio.NewReaderSize(struct{ io.Reader }{r}, 16<<10), format: format}
+}
+
+// Moves to the next line and reports whether there is one. It returns false at the end of the response body or on
+// failure, Err telling them apart.
+func (d *OverviewDecoder) Next() bool {
+	if d.err != nil {
+		return false
+	}
+	line, err := readLine(d.r, &d.buf)
+	if err != nil {
+		d.err = err
+		return false
+	}
+	d.line, d.offsets = line, d.offsets[:0]
+	for start := 0; ; {
+		i := bytes.IndexByte(line[start:], '\t')
+		if i < 0 {
+			d.offsets = append(d.offsets, [2]int{start, len(line)})
+			break
+		}
+		d.offsets = append(d.offsets, [2]int{start, start + i})
+		start += i + 1
+	}
+	return true
+}
+
+// Returns the failure that stopped Next, or nil if the end of the response body was reached.
+func (d *OverviewDecoder) Err() error {
+	if d.err == io.EOF {
+		return nil
+	}
+	return d.err
+}
+
+// Returns the current line, without its line terminator. The line is only valid until the next call to Next.
+func (d *OverviewDecoder) Line() []byte {
+	return d.line
+}
+
+// Returns the count of tab separated fields of the current line, the article number included.
+func (d *OverviewDecoder) NumFields() int {
+	return len(d.offsets)
+}
+
+// Returns the i-th field of the current line, field 0 being the article number and field 1 the subject. The field is
+// only valid until the next call to Next, and nil if the line has no such field.
+func (d *OverviewDecoder) Field(i int) []byte {
+	if i < 0 || i >= len(d.offsets) {
+		return nil
+	}
+	return d.line[d.offsets[i][0]:d.offsets[i][1]]
+}
+
+// Parses the article number of the current line without allocating.
+func (d *OverviewDecoder) ArticleNumber() (articleNumber int64, err error) {
+	var ok bool
+	if articleNumber, ok = parseDecimal(d.Field(0)); !ok {
+		err = fmt.Errorf("failed to parse article number %#v: %w", string(d.Field(0)), ErrorParsingResponse)
+	}
+	return
+}
+
+// Decodes the current line into the overview, reusing the capacity of its ExtraFields and Extra slices. The strings of
+// the overview share the memory of the line, which is reused by the next one: like Field, they are only valid until the
+// next call to Next, and must be copied to be kept any longer.
+func (d *OverviewDecoder) Decode(article *ArticleOverview) (err error) {
+	return d.decode(article, bytesString(d.line))
+}
+
+// Same as Decode, the strings of the overview sharing the memory of line, which holds the current line.
+func (d *OverviewDecoder) decode(article *ArticleOverview, line string) (err error) {
+	if len(d.offsets) < 8 {
+		err = fmt.Errorf("invalid overview line %#v: %w", string(d.line), ErrorParsingResponse)
+		return
+	}
+	if article.ArticleNumber, err = d.ArticleNumber(); err != nil {
+		return
+	}
+	field := func(i int) string {
+		return line[d.offsets[i][0]:d.offsets[i][1]]
+	}
+	article.Subject, article.From, article.Date = field(1), field(2), Timestamp(field(3))
+	article.MessageID, article.References = MessageID(field(4)), field(5)
+	// bytes and lines are best effort values
+	article.Bytes, article.Lines = parseCount(d.Field(6)), parseCount(d.Field(7))
+	article.ExtraFields, article.Extra = article.ExtraFields[:0], article.Extra[:0]
+	for i := 8; i < len(d.offsets); i++ {
+		value := field(i)
+		article.ExtraFields = append(article.ExtraFields, value)
+		var extra OverviewField
+		if len(d.format) > i-1 {
+			extra.OverviewFieldFormat = d.format[i-1]
+		} else if name, _, ok := strings.Cut(value, ":"); ok && validHeaderField(name) {
+			extra.Name, extra.Type = name, FullHeaderOverviewField
+		} else {
+			continue
+		}
+		switch extra.Type {
+		case FullHeaderOverviewField:
+			// the prefix is missing when the article has no such header
+			if len(value) > len(extra.Name) && value[len(extra.Name)] == ':' && strings.EqualFold(value[:len(extra.Name)], extra.Name) {
+				value = strings.TrimLeft(value[len(extra.Name)+1:], " ")
+			}
+		case MetadataOverviewField:
+			// servers listing :bytes or :lines among the extra fields may send header values in the fixed ones
+			if n, ok := parseDecimal(d.Field(i)); ok && strings.EqualFold(extra.Name, "bytes") {
+				article.Bytes = uint64(n)
+			} else if ok && strings.EqualFold(extra.Name, "lines") {
+				article.Lines = uint64(n)
+			}
+		}
+		extra.Value = value
+		article.Extra = append(article.Extra, extra)
+	}
+	return
+}
+
+// Reads what is left of the response body, so that the connection can go on with the next response. Decoders returned
+// by CmdOverDecoder and CmdXOverDecoder should be read to the end or closed before the connection is used again, see
+// Article.Body.
+func (d *OverviewDecoder) Close() (err error) {
+	if closer, ok := d.src.(io.Closer); ok {
+		err = closer.Close()
+	} else {
+		_, err = io.Copy(io.Discard, struct{ io.Reader }{d.src})
+	}
+	if d.err == nil {
+		d.err = io.EOF
+	}
+	return
+}
+
+// Returns a string sharing the memory of b, without copying it. The string changes along with b.
+func bytesString(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+// Parses a decimal number up to 2^63-1 without allocating.
+func parseDecimal(b []byte) (n int64, ok bool) {
+	if len(b) == 0 {
+		return
+	}
+	for _, c := range b {
+		if c < '0' || c > '9' || n > (math.MaxInt64-int64(c-'0'))/10 {
+			return 0, false
+		}
+		n = n*10 + int64(c-'0')
+	}
+	return n, true
+}
+
+// Parses the bytes or lines count of an overview line, 0 if invalid.
+func parseCount(b []byte) uint64 {
+	n, _ := parseDecimal(b)
+	return uint64(n)
+}
+
+var overviewPool = sync.Pool{New: func() any { return new(ArticleOverview) }}
+
+// Returns an overview from a pool, to decode lines into with OverviewDecoder.Decode and put back with
+// PutArticleOverview once done with.
+func GetArticleOverview() *ArticleOverview {
+	return overviewPool.Get().(*ArticleOverview)
+}
+
+// Puts back an overview returned by GetArticleOverview, keeping the capacity of its slices. Neither the overview nor
+// its slices may be used afterwards.
+func PutArticleOverview(article *ArticleOverview) {
+	*article = ArticleOverview{ExtraFields: article.ExtraFields[:0], Extra: article.Extra[:0]}
+	overviewPool.Put(article)
+}
@@ -0,0 +1,289 @@
+package nntp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"gopkg.in/option.v0"
+	"gopkg.in/rx.v0"
+)
+
+// Fetches the overviews of a range of articles of the group over several connections of the pool at once. The range is
+// split into chunks, set by WithChunkSize, which are fetched by WithParallelConns connections, each selecting the
+// group first and using OVER or XOVER as Conn.Overview does. The overviews are emitted in article number order, chunks
+// fetched ahead of the one being emitted being held in memory, up to two per connection.
+//
+// A chunk that fails is tried again on another connection, up to the attempts set by WithChunkAttempts. A connection
+// left out of sync by the failure is closed and replaced, while one that got a single line error response, such as
+// 403, goes on with the other chunks. A chunk is only tried again on a connection it failed on once it failed on all of
+// them. The download fails when a chunk runs out of attempts, the group does not exist, or the server supports neither
+// OVER nor XOVER. A zero First starts from the first article and a zero Last goes up to the last one, as reported by
+// the GROUP command.
+//
+// Cancelling ctx or unsubscribing stops the download, the connections interrupted in the middle of a command being
+// closed when returned to the pool.
+func (p *Pool) Overview(ctx context.Context, group string, articles Range, options ...ParallelOption) rx.Observable[*ArticleOverview] {
+	opts := option.New(options, WithParallelConns(4), WithChunkSize(10000), WithChunkAttempts(3))
+	return rx.Func(func(subscriber rx.Writer[*ArticleOverview]) (err error) {
+		if opts.conns < 1 || opts.chunkSize < 1 || opts.attempts < 1 {
+			err = fmt.Errorf("[nntp.Pool.Overview] connections, chunk size and attempts must be positive: %w",
+				ErrorInvalidParams)
+			return
+		}
+		if articles.First == 0 || articles.Last == 0 {
+			if articles, err = p.groupRange(ctx, group, articles); err != nil {
+				err = fmt.Errorf("[nntp.Pool.Overview] %s: %w", group, err)
+				return
+			}
+		}
+		chunks := splitRange(articles, opts.chunkSize)
+		if len(chunks) == 0 {
+			return
+		}
+
+		conns := opts.conns
+		if conns > len(chunks) {
+			conns = len(chunks)
+		}
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		queue := newChunkQueue(conns)
+		go func() {
+			<-ctx.Done()
+			queue.close()
+		}()
+		// bounds the chunks fetched ahead of the one being emitted
+		window := make(chan struct{}, 2*conns)
+		go func() {
+			for _, chunk := range chunks {
+				select {
+				case window <- struct{}{}:
+					queue.push(chunk)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		for i := 0; i < conns; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				p.fetchChunks(ctx, group, queue, opts)
+			}()
+		}
+
+		for _, chunk := range chunks {
+			select {
+			case <-chunk.done:
+			case <-ctx.Done():
+				err = fmt.Errorf("[nntp.Pool.Overview] %s: %w", group, ctx.Err())
+				return
+			}
+			if chunk.err != nil {
+				err = fmt.Errorf("[nntp.Pool.Overview] articles %d-%d of %s: %w", chunk.articles.First,
+					chunk.articles.Last, group, chunk.err)
+				return
+			}
+			for _, overview := range chunk.overviews {
+				if !subscriber.Write(overview) {
+					return
+				}
+			}
+			chunk.overviews = nil
+			<-window
+		}
+		return
+	})
+}
+
+// Same as Overview for all the articles of a group, as returned by CmdGroup.
+func (p *Pool) GroupOverview(ctx context.Context, stat *GroupStat, options ...ParallelOption) rx.Observable[*ArticleOverview] {
+	if stat.Count == 0 {
+		return rx.Empty[*ArticleOverview]()
+	}
+	return p.Overview(ctx, stat.Group, Range{stat.First, stat.Last}, options...)
+}
+
+// A part of the range downloaded by Pool.Overview.
+type overviewChunk struct {
+	articles Range
+	attempts int
+
+	// The connections the chunk failed on, guarded by the mutex of the queue.
+	failedOn map[*session]bool
+
+	// Set before done is closed.
+	overviews []*ArticleOverview
+	err       error
+	done      chan struct{}
+}
+
+// Splits the range into chunks of size article numbers at most.
+func splitRange(articles Range, size int64) (chunks []*overviewChunk) {
+	for first := articles.First; first <= articles.Last; first += size {
+		last := articles.Last
+		if articles.Last-first >= size {
+			last = first + size - 1
+		}
+		chunks = append(chunks, &overviewChunk{articles: Range{first, last}, done: make(chan struct{})})
+		if last == articles.Last {
+			break
+		}
+	}
+	return
+}
+
+// Completes the open bounds of the range with the ones of the group.
+func (p *Pool) groupRange(ctx context.Context, group string, articles Range) (r Range, err error) {
+	conn, err := p.Get(ctx)
+	if err != nil {
+		return
+	}
+	defer p.Put(conn)
+	stat, err := conn.WithContext(ctx).CmdGroup(group)
+	if err != nil {
+		return
+	}
+	r = articles
+	if r.First == 0 {
+		r.First = stat.First
+	}
+	if r.Last == 0 {
+		r.Last = stat.Last
+	}
+	if stat.Count == 0 {
+		// an empty range, whatever the bounds the server reports
+		r = Range{1, 0}
+	}
+	return
+}
+
+// The chunks waiting for a connection, retries included, each chunk being queued at most once at a time.
+type chunkQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []*overviewChunk
+	workers int
+	closed  bool
+}
+
+func newChunkQueue(workers int) *chunkQueue {
+	q := &chunkQueue{workers: workers}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *chunkQueue) push(chunk *overviewChunk) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, chunk)
+	q.cond.Broadcast()
+}
+
+// Returns the first chunk that did not fail on the connection, or that failed on as many connections as there are
+// workers, waiting for one if needed. It returns nil once the queue is closed.
+func (q *chunkQueue) next(conn *Conn) *overviewChunk {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for !q.closed {
+		for i, chunk := range q.pending {
+			if conn == nil || !chunk.failedOn[conn.session] || len(chunk.failedOn) >= q.workers {
+				q.pending = append(q.pending[:i], q.pending[i+1:]...)
+				return chunk
+			}
+		}
+		q.cond.Wait()
+	}
+	return nil
+}
+
+// Queues the chunk again after it failed on the connection.
+func (q *chunkQueue) retry(chunk *overviewChunk, conn *Conn) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if conn != nil {
+		if chunk.failedOn == nil {
+			chunk.failedOn = map[*session]bool{}
+		}
+		chunk.failedOn[conn.session] = true
+	}
+	q.pending = append(q.pending, chunk)
+	q.cond.Broadcast()
+}
+
+func (q *chunkQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// Fetches the chunks of the queue on a connection of the pool until the queue is closed. A connection left out of sync
+// by a failure is closed and replaced, the chunk being tried again on another connection.
+func (p *Pool) fetchChunks(ctx context.Context, group string, queue *chunkQueue, opts *parallelOptions) {
+	var conn *Conn
+	defer func() {
+		if conn != nil {
+			p.Put(conn)
+		}
+	}()
+	for {
+		chunk := queue.next(conn)
+		if chunk == nil {
+			return
+		}
+		var err error
+		if conn == nil {
+			var c *Conn
+			if c, err = p.Get(ctx); err == nil {
+				conn = c.WithContext(ctx)
+				if _, err = conn.CmdGroup(group); err != nil {
+					// left with no group or another one selected, the group is selected again on the next connection
+					p.Put(conn)
+					conn = nil
+				}
+			}
+		}
+		if err == nil {
+			if chunk.overviews, err = fetchChunk(conn, chunk.articles, opts.overOptions); err == nil {
+				close(chunk.done)
+				continue
+			}
+		}
+		chunk.attempts++
+		if chunk.attempts >= opts.attempts || ctx.Err() != nil || errors.Is(err, ErrorCapabilityMissing) ||
+			errors.Is(err, ResponseCodeNoSuchGroup) || errors.Is(err, ErrorPoolClosed) {
+			chunk.overviews, chunk.err = nil, err
+			close(chunk.done)
+			continue
+		}
+		failed := conn
+		if conn != nil && (conn.Err() != nil || !inSync(err)) {
+			conn.kill(fmt.Errorf("failed to fetch overviews: %w", err))
+			p.Put(conn)
+			conn = nil
+		}
+		queue.retry(chunk, failed)
+	}
+}
+
+// Fetches the overviews of a chunk, keeping only the options that do not select articles.
+func fetchChunk(conn *Conn, articles Range, options []OverOption) (overviews []*ArticleOverview, err error) {
+	set := &ArticleSet{}
+	set.AddRange(articles.First, articles.Last)
+	writer, reader := rx.Pipe[*ArticleOverview](nil)
+	conn.Overview(WithArticleSet(set), WithOverviewFormat(option.New(options).overviewFormat)).Subscribe(writer)
+	for {
+		overview, ok := reader.Read()
+		if !ok {
+			break
+		}
+		overviews = append(overviews, overview)
+	}
+	err = reader.Err()
+	return
+}
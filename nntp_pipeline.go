@@ -0,0 +1,300 @@
+package nntp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+
+	"gopkg.in/option.v0"
+	"gopkg.in/rx.v0"
+	"gopkg.in/textproto.v0"
+)
+
+// An article retrieval command that can be pipelined, along with the parts of the article its response carries.
+type articleCommand struct {
+	verb         string
+	code         ResponseCode
+	header, body bool
+}
+
+var (
+	articleCommandArticle = articleCommand{"ARTICLE", ResponseCodeArticleFollows, true, true} // 220
+	articleCommandHead    = articleCommand{"HEAD", ResponseCodeHeadFollows, true, false}      // 221
+	articleCommandBody    = articleCommand{"BODY", ResponseCodeBodyFollows, false, true}      // 222
+	articleCommandStat    = articleCommand{"STAT", ResponseCodeArticleSelected, false, false} // 223
+)
+
+// Pipelines an ARTICLE command for each request, selected with ArticleMessageID or ArticleNumber, and emits one result
+// per request in the order of the requests. Up to the window set by WithStreamWindow commands are sent ahead of the
+// responses being read. A request refused by the server, e.g. with 430 for a missing article, yields a result carrying
+// the response as error and the stream goes on; only transport and protocol failures end the stream, along with a
+// request by article number while no group is selected, which fails with ErrorNoGroupSelected without being sent.
+//
+// Bodies are read straight from the connection: the next response is only read once the body of the current article is
+// read to EOF or closed, Body implementing io.Closer to drain what is left. Use WithBufferedBodies to have bodies read
+// in full before they are emitted instead.
+func (conn *Conn) CmdStreamArticle(requests rx.Observable[ArticleOption], options ...StreamOption) rx.Observable[*ArticleResult] {
+	return conn.streamArticles("CmdStreamArticle", articleCommandArticle, requests, options)
+}
+
+// Same as CmdStreamArticle with the HEAD command.
+func (conn *Conn) CmdStreamHead(requests rx.Observable[ArticleOption], options ...StreamOption) rx.Observable[*ArticleResult] {
+	return conn.streamArticles("CmdStreamHead", articleCommandHead, requests, options)
+}
+
+// Same as CmdStreamArticle with the BODY command.
+func (conn *Conn) CmdStreamBody(requests rx.Observable[ArticleOption], options ...StreamOption) rx.Observable[*ArticleResult] {
+	return conn.streamArticles("CmdStreamBody", articleCommandBody, requests, options)
+}
+
+// Same as CmdStreamArticle with the STAT command.
+func (conn *Conn) CmdStreamStat(requests rx.Observable[ArticleOption], options ...StreamOption) rx.Observable[*ArticleResult] {
+	return conn.streamArticles("CmdStreamStat", articleCommandStat, requests, options)
+}
+
+func (conn *Conn) streamArticles(name string, command articleCommand, requests rx.Observable[ArticleOption], options []StreamOption) rx.Observable[*ArticleResult] {
+	opts := option.New(options, WithStreamWindow(64))
+	return rx.Func(func(subscriber rx.Writer[*ArticleResult]) (err error) {
+		if opts.window < 1 {
+			err = fmt.Errorf("[nntp.%s] window must be positive: %w", name, ErrorInvalidParams)
+			return
+		}
+		end, err := conn.begin(name)
+		if err != nil {
+			return
+		}
+		var (
+			mu      sync.Mutex  // serializes the writer sending commands with the reader stopping
+			stopped bool        // set under mu when the reader stops, no command may be sent afterwards
+			pending *streamBody // the body being read by the consumer, if any
+			refused error       // set before writerDone is closed when a request cannot be sent
+		)
+		slots := make(chan struct{}, opts.window)
+		sent := make(chan *articleOptions, opts.window)
+		writerDone := make(chan struct{})
+
+		requestWriter, requestReader := rx.Pipe[ArticleOption](subscriber)
+		subscriber.Go(func() (err error) {
+			defer close(writerDone)
+			for {
+				request, ok := requestReader.Read()
+				if !ok {
+					return
+				}
+				select {
+				case slots <- struct{}{}:
+				case <-subscriber.Dying():
+					return
+				}
+				ropts := option.New([]ArticleOption{request})
+				if ropts.messageID == "" {
+					// the responses to the requests already sent are read before the stream fails
+					if refused = conn.requireGroup(name); refused != nil {
+						return
+					}
+				}
+				mu.Lock()
+				if stopped {
+					mu.Unlock()
+					return
+				}
+				if ropts.messageID != "" {
+					err = conn.PrintfLine("%s %s", command.verb, ropts.messageID.Full())
+				} else if ropts.articleNumber != 0 {
+					err = conn.PrintfLine("%s %d", command.verb, ropts.articleNumber)
+				} else {
+					err = conn.PrintfLine("%s", command.verb)
+				}
+				if err != nil {
+					err = fmt.Errorf("[nntp.%s] failed to send %s command: %w", name, command.verb, err)
+					conn.kill(err)
+					mu.Unlock()
+					return
+				}
+				sent <- ropts
+				mu.Unlock()
+			}
+		})
+		subscriber.Go(func() (err error) {
+			defer end(&err)
+			defer func() {
+				mu.Lock()
+				stopped = true
+				if len(sent) > 0 {
+					conn.kill(fmt.Errorf("%d %s responses left unread", len(sent), command.verb))
+				} else if pending != nil && !pending.finished() {
+					conn.kill(fmt.Errorf("%s body left unread", command.verb))
+				}
+				mu.Unlock()
+			}()
+			for {
+				var ropts *articleOptions
+				select {
+				case ropts = <-sent:
+				case <-writerDone:
+					select {
+					case ropts = <-sent:
+					default:
+						err = refused
+						return
+					}
+				case <-subscriber.Dying():
+					return
+				}
+				result := &ArticleResult{ArticleNumber: ropts.articleNumber}
+				if ropts.messageID != "" {
+					result.MessageID = ropts.messageID.Full()
+				}
+				if result.Article, result.Err, err = conn.readArticleResponse(name, command, ropts, opts.buffered); err != nil {
+					return
+				}
+				<-slots
+				if result.Article != nil {
+					pending, _ = result.Article.Body.(*streamBody)
+				}
+				if !subscriber.Write(result) {
+					return
+				}
+				if pending != nil {
+					select {
+					case <-pending.done:
+					case <-subscriber.Dying():
+						return
+					}
+					if pending.err != nil {
+						err = fmt.Errorf("[nntp.%s] failed to read %s payload: %w", name, command.verb, pending.err)
+						return
+					}
+					pending = nil
+				}
+			}
+		})
+		requests.Subscribe(requestWriter)
+		return
+	})
+}
+
+// Reads the response to a pipelined article command, up to the body unless buffered is set. A refused request is
+// reported as response, leaving err for the failures that end the stream.
+func (conn *Conn) readArticleResponse(name string, command articleCommand, opts *articleOptions, buffered bool) (article *Article, response *Error, err error) {
+	code, msg, err := conn.ReadCodeLine(0)
+	if err != nil {
+		err = fmt.Errorf("[nntp.%s] failed to read %s response: %w", name, command.verb, err)
+		return
+	}
+	if ResponseCode(code) != command.code {
+		if code < 400 {
+			// another success response may be followed by data the stream cannot tell apart from the next response
+			err = fmt.Errorf("[nntp.%s] unexpected response %d %#v: %w", name, code, msg, ErrorParsingResponse)
+		} else {
+			response = &Error{ResponseCode(code), msg}
+		}
+		return
+	}
+	article = new(Article)
+	if _, err = fmt.Sscanf(msg, "%d %s", &article.ArticleNumber, &article.MessageID); err != nil {
+		err = fmt.Errorf("[nntp.%s] failed to parse %s command status line: %#v: %w", name, command.verb, msg, ErrorParsingResponse)
+		return
+	}
+	if opts.messageID == "" {
+		conn.setArticleNumber(article.ArticleNumber)
+	}
+	if command.header && !command.body {
+		if article.Header, err = conn.readHeadBlock(); err != nil {
+			err = fmt.Errorf("[nntp.%s] failed to parse MIME header: %#v: %w", name, msg, ErrorParsingResponse)
+			return
+		}
+	} else if command.header {
+		if article.Header, err = conn.ReadMIMEHeader(); err != nil {
+			err = fmt.Errorf("[nntp.%s] failed to parse MIME header: %#v: %w", name, msg, ErrorParsingResponse)
+			return
+		}
+	}
+	if command.body {
+		var r io.Reader
+		if opts.dotEncodedBody {
+			r = conn.DotReader(textproto.DisableDotDecoding)
+		} else {
+			r = conn.DotReader()
+		}
+		if !buffered {
+			article.Body = newStreamBody(r)
+			return
+		}
+		var body []byte
+		if body, err = io.ReadAll(r); err != nil {
+			err = fmt.Errorf("[nntp.%s] failed to read %s payload: %w", name, command.verb, err)
+			return
+		}
+		article.Body = bytes.NewReader(body)
+	}
+	return
+}
+
+// Reads the header of a HEAD response, which is terminated by a single dot instead of an empty line.
+func (conn *Conn) readHeadBlock() (header textproto.MIMEHeader, err error) {
+	// textproto's ReadDotBytes fails on lines longer than its buffer, see session.DotReader
+	block, err := io.ReadAll(conn.DotReader())
+	if err != nil {
+		return
+	}
+	header, err = textproto.NewReader(bufio.NewReader(bytes.NewReader(block))).ReadMIMEHeader()
+	if err == io.EOF {
+		err = nil
+	}
+	return
+}
+
+// The body of an article emitted by a pipelined stream, read straight from the connection. It is done once read to EOF,
+// failed or closed, which lets the stream go on with the next response.
+type streamBody struct {
+	r    io.Reader
+	once sync.Once
+	done chan struct{}
+	err  error // the read failure, set before done is closed
+}
+
+func newStreamBody(r io.Reader) *streamBody {
+	return &streamBody{r: r, done: make(chan struct{})}
+}
+
+func (b *streamBody) Read(p []byte) (n int, err error) {
+	select {
+	case <-b.done:
+		return 0, io.EOF
+	default:
+	}
+	if n, err = b.r.Read(p); err != nil {
+		b.finish(err)
+	}
+	return
+}
+
+// Drains what is left of the body.
+func (b *streamBody) Close() (err error) {
+	// the reader is wrapped to hide any WriteTo method, textproto's one does not stop at the end of the body
+	if _, err = io.Copy(io.Discard, struct{ io.Reader }{b}); err != nil {
+		b.finish(err)
+	}
+	return
+}
+
+func (b *streamBody) finish(err error) {
+	b.once.Do(func() {
+		if err != io.EOF {
+			b.err = err
+		}
+		close(b.done)
+	})
+}
+
+func (b *streamBody) finished() bool {
+	select {
+	case <-b.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,231 @@
+package nntp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// A Pool keeps a set of authenticated connections to a single server. Connections are dialed lazily by Get, handed
+// out to a single user at a time, and returned with Put for later reuse. The zero value is not usable, at least Addr
+// must be set. A Pool must not be copied after first use.
+type Pool struct {
+	// Dialer is the optional dialer used to open connections. A nil Dialer is equivalent to the Dialer zero value.
+	Dialer *Dialer
+
+	// Network and Addr of the server, as accepted by net.Dial. Network defaults to "tcp".
+	Network string
+	Addr    string
+
+	// TLS selects implicit TLS with Dialer.DialTLS instead of Dialer.Dial.
+	TLS bool
+
+	// Credentials sent with AUTHINFO USER/PASS right after connecting. No authentication is done if Username is empty.
+	Username string
+	Password string
+
+	// ModeReader issues MODE READER right after connecting and authenticating.
+	ModeReader bool
+
+	// MaxConns is the maximum number of connections checked out at the same time. Get blocks when the limit is
+	// reached, until a connection is returned. Zero means no limit.
+	MaxConns int
+
+	// IdleTimeout is how long a returned connection is kept before being closed. Zero means idle connections are kept
+	// until the pool is closed.
+	IdleTimeout time.Duration
+
+	// HealthCheckAfter is how long a connection may stay idle before Get checks it is still alive with the DATE
+	// command. Zero disables health checks.
+	HealthCheckAfter time.Duration
+
+	once    sync.Once
+	slots   chan struct{}
+	mu      sync.Mutex
+	idle    []idleConn
+	closed  bool
+	janitor chan struct{}
+}
+
+type idleConn struct {
+	conn  *Conn
+	since time.Time
+}
+
+func (p *Pool) init() {
+	p.once.Do(func() {
+		if p.MaxConns > 0 {
+			p.slots = make(chan struct{}, p.MaxConns)
+		}
+		if p.IdleTimeout > 0 {
+			p.janitor = make(chan struct{})
+			go p.evictLoop(p.janitor)
+		}
+	})
+}
+
+// Checks out a connection, reusing an idle one or dialing a new one. It blocks while MaxConns connections are checked
+// out, until one is returned or ctx is done. The connection must be given back with Put once done, even if it failed.
+func (p *Pool) Get(ctx context.Context) (conn *Conn, err error) {
+	p.init()
+	if p.slots != nil {
+		select {
+		case p.slots <- struct{}{}:
+		case <-ctx.Done():
+			err = fmt.Errorf("[nntp.Pool.Get] waiting for a connection to %s: %w", p.Addr, ctx.Err())
+			return
+		}
+		defer func() {
+			if err != nil {
+				<-p.slots
+			}
+		}()
+	}
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			err = fmt.Errorf("[nntp.Pool.Get] %s: %w", p.Addr, ErrorPoolClosed)
+			return
+		}
+		n := len(p.idle)
+		if n == 0 {
+			p.mu.Unlock()
+			break
+		}
+		// most recently used first, leaving the older ones to expire
+		c := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		if c.conn.Err() != nil {
+			c.conn.Close()
+			continue
+		}
+		if p.HealthCheckAfter > 0 && time.Since(c.since) >= p.HealthCheckAfter {
+			if _, e := c.conn.WithContext(ctx).CmdDate(); e != nil {
+				c.conn.Close()
+				if ctx.Err() != nil {
+					err = fmt.Errorf("[nntp.Pool.Get] health check of connection to %s: %w", p.Addr, e)
+					return
+				}
+				continue
+			}
+		}
+		conn = c.conn
+		return
+	}
+	return p.dial(ctx)
+}
+
+// Returns a connection checked out with Get. Connections left in an unknown protocol state, see Conn.Err, are closed
+// instead of being kept for reuse, as are connections with an article body not read to the end, which would hold the
+// response turn of the next user and may take as long to drain as to read.
+func (p *Pool) Put(conn *Conn) {
+	p.init()
+	if p.slots != nil {
+		defer func() { <-p.slots }()
+	}
+	// views bound to a context must not outlive it
+	conn = conn.WithContext(context.Background())
+	p.mu.Lock()
+	if p.closed || conn.Err() != nil || conn.hasPendingBody() {
+		p.mu.Unlock()
+		conn.Close()
+		return
+	}
+	p.idle = append(p.idle, idleConn{conn, time.Now()})
+	p.mu.Unlock()
+}
+
+// Closes all idle connections. Connections still checked out are closed when returned with Put.
+func (p *Pool) Close() (err error) {
+	p.init()
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+	if p.janitor != nil {
+		close(p.janitor)
+	}
+	for _, c := range idle {
+		if e := c.conn.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+// Returns the number of idle connections.
+func (p *Pool) Idle() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.idle)
+}
+
+func (p *Pool) dial(ctx context.Context) (conn *Conn, err error) {
+	dialer := p.Dialer
+	if dialer == nil {
+		dialer = &Dialer{}
+	}
+	network := p.Network
+	if network == "" {
+		network = "tcp"
+	}
+	if p.TLS {
+		conn, err = dialer.DialTLS(ctx, network, p.Addr)
+	} else {
+		conn, err = dialer.Dial(ctx, network, p.Addr)
+	}
+	if err != nil {
+		conn, err = nil, fmt.Errorf("[nntp.Pool.Get] failed to connect to %s: %w", p.Addr, err)
+		return
+	}
+	c := conn.WithContext(ctx)
+	if p.Username != "" {
+		err = c.CmdAuthinfo(p.Username, p.Password)
+	}
+	if err == nil && p.ModeReader {
+		_, err = c.CmdModeReader()
+	}
+	if err != nil {
+		conn.Close()
+		conn, err = nil, fmt.Errorf("[nntp.Pool.Get] failed to set up connection to %s: %w", p.Addr, err)
+	}
+	return
+}
+
+func (p *Pool) evictLoop(stop chan struct{}) {
+	ticker := time.NewTicker(p.IdleTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.evict()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Closes the connections idle for longer than IdleTimeout.
+func (p *Pool) evict() {
+	deadline := time.Now().Add(-p.IdleTimeout)
+	p.mu.Lock()
+	// idle connections are sorted from the least to the most recently returned
+	n := 0
+	for n < len(p.idle) && p.idle[n].since.Before(deadline) {
+		n++
+	}
+	expired := append([]idleConn{}, p.idle[:n]...)
+	p.idle = append(p.idle[:0], p.idle[n:]...)
+	p.mu.Unlock()
+	for _, c := range expired {
+		c.conn.Close()
+	}
+}
@@ -0,0 +1,278 @@
+package nntp
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A SASLMechanism implements the client side of a SASL authentication mechanism for the AUTHINFO SASL command.
+type SASLMechanism interface {
+	// Name returns the mechanism name as registered with IANA, e.g. "PLAIN".
+	Name() string
+
+	// Start begins the exchange and returns the initial response. A nil initial response means the mechanism waits for
+	// the first server challenge, while an empty non-nil one is sent as an empty initial response.
+	Start() (initialResponse []byte, err error)
+
+	// Next returns the response to a server challenge. It is also given the additional data the server sends along
+	// with a successful authentication, in which case the response is ignored and an error indicates the server failed
+	// to prove its identity.
+	Next(challenge []byte) (response []byte, err error)
+}
+
+// A SASLCompleter is a SASLMechanism whose exchange must run to its end for the authentication to be trusted, typically
+// because the server proves its identity in the last step. CmdAuthinfoSASL fails when the server accepts the
+// authentication before such a mechanism is complete.
+type SASLCompleter interface {
+	// Complete reports whether the exchange reached its end, the identity of the server being verified.
+	Complete() bool
+}
+
+type saslPlain struct {
+	identity, username, password string
+}
+
+// The PLAIN mechanism from RFC 4616. The identity to act as is usually left empty to derive it from the username.
+func SASLPlain(identity, username, password string) SASLMechanism {
+	return &saslPlain{identity, username, password}
+}
+
+func (m *saslPlain) Name() string {
+	return "PLAIN"
+}
+
+func (m *saslPlain) Start() (ir []byte, err error) {
+	ir = []byte(m.identity + "\x00" + m.username + "\x00" + m.password)
+	return
+}
+
+func (m *saslPlain) Next(challenge []byte) (response []byte, err error) {
+	if len(challenge) > 0 {
+		err = fmt.Errorf("PLAIN: %w", ErrorSASLUnexpectedChallenge)
+	}
+	return
+}
+
+type saslCramMD5 struct {
+	username, secret string
+	done             bool
+}
+
+// The CRAM-MD5 mechanism from RFC 2195.
+func SASLCramMD5(username, secret string) SASLMechanism {
+	return &saslCramMD5{username: username, secret: secret}
+}
+
+func (m *saslCramMD5) Name() string {
+	return "CRAM-MD5"
+}
+
+func (m *saslCramMD5) Start() (ir []byte, err error) {
+	return
+}
+
+func (m *saslCramMD5) Next(challenge []byte) (response []byte, err error) {
+	if m.done {
+		if len(challenge) > 0 {
+			err = fmt.Errorf("CRAM-MD5: %w", ErrorSASLUnexpectedChallenge)
+		}
+		return
+	}
+	m.done = true
+	d := hmac.New(md5.New, []byte(m.secret))
+	d.Write(challenge)
+	response = []byte(m.username + " " + hex.EncodeToString(d.Sum(nil)))
+	return
+}
+
+type saslExternal struct {
+	identity string
+}
+
+// The EXTERNAL mechanism from RFC 4422, usually relying on a TLS client certificate. The identity to act as may be left
+// empty to let the server derive it from the external credentials.
+func SASLExternal(identity string) SASLMechanism {
+	return &saslExternal{identity}
+}
+
+func (m *saslExternal) Name() string {
+	return "EXTERNAL"
+}
+
+func (m *saslExternal) Start() (ir []byte, err error) {
+	ir = []byte(m.identity)
+	return
+}
+
+func (m *saslExternal) Next(challenge []byte) (response []byte, err error) {
+	if len(challenge) > 0 {
+		err = fmt.Errorf("EXTERNAL: %w", ErrorSASLUnexpectedChallenge)
+	}
+	return
+}
+
+type saslOAuthBearer struct {
+	username, token string
+	failed          bool
+}
+
+// The OAUTHBEARER mechanism from RFC 7628.
+func SASLOAuthBearer(username, token string) SASLMechanism {
+	return &saslOAuthBearer{username: username, token: token}
+}
+
+func (m *saslOAuthBearer) Name() string {
+	return "OAUTHBEARER"
+}
+
+func (m *saslOAuthBearer) Start() (ir []byte, err error) {
+	gs2header := "n,,"
+	if m.username != "" {
+		gs2header = "n,a=" + scramEscape(m.username) + ","
+	}
+	ir = []byte(gs2header + "\x01auth=Bearer " + m.token + "\x01\x01")
+	return
+}
+
+func (m *saslOAuthBearer) Next(challenge []byte) (response []byte, err error) {
+	if m.failed {
+		err = fmt.Errorf("OAUTHBEARER: %w", ErrorSASLUnexpectedChallenge)
+		return
+	}
+	// the server sends a JSON error status as challenge, to which the client must reply with a dummy response, before
+	// the exchange fails with 481
+	m.failed = true
+	response = []byte{0x01}
+	return
+}
+
+// The highest iteration count accepted from the server, so that a hostile server cannot keep the client busy hashing.
+// RFC 7677 recommends at least 4096 iterations.
+const scramMaxIterations = 1 << 20
+
+type saslScramSHA256 struct {
+	username, password string
+	step               int
+	clientNonce        string
+	clientFirstBare    string
+	serverSignature    []byte
+}
+
+// The SCRAM-SHA-256 mechanism from RFC 7677, without channel binding. Usernames are sent as is, SASLprep normalization
+// is left to the caller.
+func SASLScramSHA256(username, password string) SASLMechanism {
+	return &saslScramSHA256{username: username, password: password}
+}
+
+func (m *saslScramSHA256) Name() string {
+	return "SCRAM-SHA-256"
+}
+
+func (m *saslScramSHA256) Start() (ir []byte, err error) {
+	nonce := make([]byte, 24)
+	if _, err = rand.Read(nonce); err != nil {
+		err = fmt.Errorf("SCRAM-SHA-256: failed to generate nonce: %w", err)
+		return
+	}
+	m.step, m.clientNonce = 1, base64.RawStdEncoding.EncodeToString(nonce)
+	m.clientFirstBare = "n=" + scramEscape(m.username) + ",r=" + m.clientNonce
+	ir = []byte("n,," + m.clientFirstBare)
+	return
+}
+
+func (m *saslScramSHA256) Next(challenge []byte) (response []byte, err error) {
+	attrs := scramAttributes(string(challenge))
+	if e, ok := attrs['e']; ok {
+		err = fmt.Errorf("SCRAM-SHA-256: server error %#v: %w", e, ErrorSASLUnexpectedChallenge)
+		return
+	}
+	switch m.step {
+	case 1:
+		nonce, salt64, iterations := attrs['r'], attrs['s'], attrs['i']
+		if !strings.HasPrefix(nonce, m.clientNonce) || len(nonce) == len(m.clientNonce) {
+			err = fmt.Errorf("SCRAM-SHA-256: invalid server nonce %#v: %w", nonce, ErrorSASLUnexpectedChallenge)
+			return
+		}
+		var salt []byte
+		if salt, err = base64.StdEncoding.DecodeString(salt64); err != nil {
+			err = fmt.Errorf("SCRAM-SHA-256: invalid salt %#v: %w", salt64, ErrorSASLUnexpectedChallenge)
+			return
+		}
+		var i int
+		if i, err = strconv.Atoi(iterations); err != nil || i < 1 || i > scramMaxIterations {
+			err = fmt.Errorf("SCRAM-SHA-256: invalid iteration count %#v: %w", iterations, ErrorSASLUnexpectedChallenge)
+			return
+		}
+		saltedPassword := scramHi([]byte(m.password), salt, i)
+		clientKey := scramHMAC(saltedPassword, []byte("Client Key"))
+		storedKey := sha256.Sum256(clientKey)
+		clientFinal := "c=biws,r=" + nonce
+		authMessage := []byte(m.clientFirstBare + "," + string(challenge) + "," + clientFinal)
+		proof := scramHMAC(storedKey[:], authMessage)
+		for j := range proof {
+			proof[j] ^= clientKey[j]
+		}
+		m.serverSignature = scramHMAC(scramHMAC(saltedPassword, []byte("Server Key")), authMessage)
+		m.step = 2
+		response = []byte(clientFinal + ",p=" + base64.StdEncoding.EncodeToString(proof))
+	case 2:
+		var signature []byte
+		if signature, err = base64.StdEncoding.DecodeString(attrs['v']); err != nil {
+			err = fmt.Errorf("SCRAM-SHA-256: invalid server signature %#v: %w", attrs['v'], ErrorSASLUnexpectedChallenge)
+			return
+		}
+		if !hmac.Equal(signature, m.serverSignature) {
+			err = fmt.Errorf("SCRAM-SHA-256: server signature mismatch: %w", ErrorSASLUnexpectedChallenge)
+			return
+		}
+		m.step = 3
+	default:
+		err = fmt.Errorf("SCRAM-SHA-256: %w", ErrorSASLUnexpectedChallenge)
+	}
+	return
+}
+
+// Reports whether the server signature was verified.
+func (m *saslScramSHA256) Complete() bool {
+	return m.step == 3
+}
+
+func scramEscape(s string) string {
+	return strings.NewReplacer("=", "=3D", ",", "=2C").Replace(s)
+}
+
+func scramAttributes(s string) map[byte]string {
+	attrs := make(map[byte]string)
+	for _, attr := range strings.Split(s, ",") {
+		if len(attr) >= 2 && attr[1] == '=' {
+			attrs[attr[0]] = attr[2:]
+		}
+	}
+	return attrs
+}
+
+func scramHMAC(key, data []byte) []byte {
+	d := hmac.New(sha256.New, key)
+	d.Write(data)
+	return d.Sum(nil)
+}
+
+// Hi() from RFC 5802, which is PBKDF2 with HMAC-SHA-256 producing a single block.
+func scramHi(password, salt []byte, iterations int) []byte {
+	u := scramHMAC(password, append(append([]byte{}, salt...), 0, 0, 0, 1))
+	result := append([]byte{}, u...)
+	for i := 1; i < iterations; i++ {
+		u = scramHMAC(password, u)
+		for j := range result {
+			result[j] ^= u[j]
+		}
+	}
+	return result
+}
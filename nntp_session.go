@@ -0,0 +1,119 @@
+package nntp
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// The mode of a session, see RFC 3977 section 3.4.2 for reader and transit modes, and RFC 4644 for streaming.
+type SessionMode int
+
+const (
+	// The mode is not known until the capabilities are read or a MODE command succeeds.
+	SessionModeUnknown SessionMode = iota
+
+	// The reader commands are available.
+	SessionModeReader
+
+	// Only the transit commands are available, MODE READER must be issued before reader commands.
+	SessionModeTransit
+
+	// The transit mode with the streaming commands enabled by MODE STREAM.
+	SessionModeStream
+)
+
+func (mode SessionMode) String() string {
+	switch mode {
+	case SessionModeReader:
+		return "reader"
+	case SessionModeTransit:
+		return "transit"
+	case SessionModeStream:
+		return "stream"
+	default:
+		return "unknown"
+	}
+}
+
+// A snapshot of the state of a session, as tracked from the successful commands issued on the connection.
+type SessionState struct {
+	// The currently selected newsgroup, as reported by the last successful GROUP or LISTGROUP command. Nil if no group
+	// is selected.
+	Group *GroupStat
+
+	// The current article number in the selected group, 0 if it is invalid or unknown.
+	ArticleNumber int64
+
+	// Whether the server allows posting, as told by the welcome message or the MODE READER command.
+	PostingAllowed bool
+
+	// Whether AUTHINFO USER/PASS or AUTHINFO SASL succeeded.
+	Authenticated bool
+
+	Mode       SessionMode
+	TLS        bool
+	Compressed bool
+}
+
+// Returns the current state of the session. The state is read-only, it changes as commands succeed.
+func (conn *Conn) State() (state SessionState) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	state = conn.state
+	if state.Group != nil {
+		group := *state.Group
+		state.Group = &group
+	}
+	_, state.TLS = conn.netconn.(*tls.Conn)
+	state.Compressed = conn.compressed
+	return
+}
+
+// Updates the state of the session.
+func (conn *Conn) updateState(update func(state *SessionState)) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	update(&conn.state)
+}
+
+// Selects the group, setting the current article number to its first article as RFC 3977 requires.
+func (conn *Conn) selectGroup(group *GroupStat) {
+	selected := *group
+	conn.updateState(func(state *SessionState) {
+		state.Group = &selected
+		state.ArticleNumber = 0
+		if selected.Count > 0 {
+			state.ArticleNumber = selected.First
+		}
+	})
+}
+
+func (conn *Conn) setArticleNumber(articleNumber int64) {
+	conn.updateState(func(state *SessionState) {
+		state.ArticleNumber = articleNumber
+	})
+}
+
+// Counts the GROUP and LISTGROUP commands in flight, which may select a group for the commands pipelined after them.
+// The returned function must be deferred.
+func (conn *Conn) selectingGroup() (done func()) {
+	conn.mu.Lock()
+	conn.groupsPending++
+	conn.mu.Unlock()
+	return func() {
+		conn.mu.Lock()
+		conn.groupsPending--
+		conn.mu.Unlock()
+	}
+}
+
+// Fails fast for commands referring to the current group or to an article number when no group is selected, unless a
+// GROUP or LISTGROUP command that may select one is still in flight.
+func (conn *Conn) requireGroup(name string) (err error) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if conn.state.Group == nil && conn.groupsPending == 0 {
+		err = fmt.Errorf("[nntp.%s] %w", name, ErrorNoGroupSelected)
+	}
+	return
+}
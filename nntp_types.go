@@ -2,20 +2,22 @@ package nntp
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type GroupStat struct {
-	Count int
-	First int
-	Last  int
+	Count int64
+	First int64
+	Last  int64
 	Group string
 }
 
 type GroupListItem struct {
 	Group      string
-	Last       int
-	First      int
+	Last       int64
+	First      int64
 	Permission GroupPermission
 }
 
@@ -24,9 +26,48 @@ type GroupDescriptionListItem struct {
 	Description string
 }
 
+// A line of LIST ACTIVE.TIMES, telling when and by whom a newsgroup was created.
+type GroupCreationListItem struct {
+	Group   string
+	Created time.Time
+
+	// The creator, usually an email address, or another identifying string.
+	Creator string
+}
+
+// A line of LIST DISTRIB.PATS. A client posting to groups matching Wildmat should use Distribution as default
+// Distribution header, the pattern with the highest weight winning among the matching ones.
+type DistributionPatternListItem struct {
+	Weight       int
+	Wildmat      string
+	Distribution string
+}
+
+// A line of LIST DISTRIBUTIONS, describing a value of the Distribution header.
+type DistributionListItem struct {
+	Distribution string
+	Description  string
+}
+
+// A line of LIST MODERATORS. Articles posted to a moderated group matching Pattern are mailed to Address, where "%s"
+// stands for the group name with its dots replaced by dashes and "%%" for a single percent sign.
+type ModeratorListItem struct {
+	Pattern string
+	Address string
+}
+
+// A line of LIST COUNTS, an INN extension of LIST ACTIVE carrying the estimated number of articles in the group.
+type GroupCountListItem struct {
+	Group      string
+	Last       int64
+	First      int64
+	Count      int64
+	Permission GroupPermission
+}
+
 type Range struct {
-	First int
-	Last  int
+	First int64
+	Last  int64
 }
 
 func (r Range) String() string {
@@ -35,13 +76,13 @@ func (r Range) String() string {
 		first = 1
 	}
 	if r.Last == 0 {
-		return fmt.Sprintf("%d-", r.First)
+		return fmt.Sprintf("%d-", first)
 	}
-	return fmt.Sprintf("%d-%d", r.First, r.Last)
+	return fmt.Sprintf("%d-%d", first, r.Last)
 }
 
 type ArticleOverview struct {
-	ArticleNumber int
+	ArticleNumber int64
 	Subject       string
 	From          string
 	Date          Timestamp
@@ -49,7 +90,94 @@ type ArticleOverview struct {
 	References    string
 	Bytes         uint64
 	Lines         uint64
-	ExtraFields   []string
+
+	// The fields after Lines, as sent by the server.
+	ExtraFields []string
+
+	// The fields after Lines named after the overview format, see CmdListOverviewFmt, with the "Name: " prefix of full
+	// header fields stripped. When the format is unknown the fields carrying such a prefix are taken as full header
+	// fields and the others are left out.
+	Extra []OverviewField
+}
+
+// A field of an overview line beyond the fixed ones.
+type OverviewField struct {
+	OverviewFieldFormat
+	Value string
+}
+
+// Returns the value of the named field of the overview, the name being compared case-insensitively: either one of the
+// fixed fields such as "Subject" or "Message-ID", or one of the extra fields such as "Xref". Metadata items are named
+// with their leading colon, e.g. ":bytes".
+func (o *ArticleOverview) Field(name string) (value string, ok bool) {
+	switch strings.ToLower(name) {
+	case "subject":
+		return o.Subject, true
+	case "from":
+		return o.From, true
+	case "date":
+		return string(o.Date), true
+	case "message-id":
+		return string(o.MessageID), true
+	case "references":
+		return o.References, true
+	case "bytes", ":bytes":
+		return strconv.FormatUint(o.Bytes, 10), true
+	case "lines", ":lines":
+		return strconv.FormatUint(o.Lines, 10), true
+	}
+	for _, field := range o.Extra {
+		fieldName := field.Name
+		if field.Type == MetadataOverviewField {
+			fieldName = ":" + fieldName
+		}
+		if strings.EqualFold(fieldName, name) {
+			return field.Value, true
+		}
+	}
+	return
+}
+
+// A single header field value returned by the HDR or XHDR commands.
+type ArticleHeader struct {
+	// The article number, or 0 if the article was requested by its message-id.
+	ArticleNumber int64
+	Value         string
+}
+
+// The outcome of one request of a pipelined article stream such as CmdStreamBody.
+type ArticleResult struct {
+	// The message-id or the article number of the request, as given to the stream.
+	MessageID     MessageID
+	ArticleNumber int64
+
+	// The article, nil if the server refused the request.
+	Article *Article
+
+	// The response refusing the request, typically ResponseCodeNoSuchArticleId (430) or
+	// ResponseCodeNoSuchArticleNumber (423). The connection remains usable and the stream goes on with the next request.
+	Err *Error
+}
+
+// The outcome of offering an article with CmdStreamFeed.
+type FeedResult struct {
+	MessageID MessageID
+
+	// The response to CHECK: ResponseCodeCheckSend (238), ResponseCodeCheckLater (431) or ResponseCodeCheckUnwanted
+	// (438). Zero if the article could not be offered.
+	Check ResponseCode
+
+	// The response to TAKETHIS: ResponseCodeTakeThisSuccess (239) or ResponseCodeTakeThisRejected (439). Zero if the
+	// article was not sent.
+	TakeThis ResponseCode
+
+	// Set when the article could not be offered or loaded.
+	Err error
+}
+
+// Reports whether the server accepted the article.
+func (r *FeedResult) Transferred() bool {
+	return r.TakeThis == ResponseCodeTakeThisSuccess
 }
 
 func FullMessageID(messageID string) string {
@@ -0,0 +1,162 @@
+package nntp
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/rx.v0"
+)
+
+// The content of an Xref header, telling where the server filed an article: the name of the server and the number of
+// the article in each of the newsgroups it was posted to.
+type Xref struct {
+	Server    string
+	Locations []XrefLocation
+}
+
+// A newsgroup an article is filed in, along with its number there.
+type XrefLocation struct {
+	Group         string
+	ArticleNumber int64
+}
+
+// Parses the value of an Xref header, e.g. "news.example.com misc.test:3000237 alt.test:12". The "Xref:" prefix of
+// overview lines is accepted as well.
+func ParseXref(value string) (xref *Xref, err error) {
+	if len(value) >= 5 && strings.EqualFold(value[:5], "Xref:") {
+		value = value[5:]
+	}
+	fields := strings.Fields(value)
+	if len(fields) < 2 {
+		err = fmt.Errorf("[nntp.ParseXref] invalid Xref %#v: %w", value, ErrorParsingResponse)
+		return
+	}
+	xref = &Xref{Server: fields[0], Locations: make([]XrefLocation, len(fields)-1)}
+	for i, field := range fields[1:] {
+		location := &xref.Locations[i]
+		colon := strings.LastIndexByte(field, ':')
+		if colon < 1 {
+			err = fmt.Errorf("[nntp.ParseXref] invalid Xref location %#v: %w", field, ErrorParsingResponse)
+			xref = nil
+			return
+		}
+		location.Group = field[:colon]
+		if location.ArticleNumber, err = parseArticleNumber(field[colon+1:]); err != nil {
+			err = fmt.Errorf("[nntp.ParseXref] invalid Xref location %#v: %w", field, ErrorParsingResponse)
+			xref = nil
+			return
+		}
+	}
+	return
+}
+
+// Returns the parsed Xref field of the overview, nil if the server sent none, see ArticleOverview.Field.
+func (o *ArticleOverview) Xref() (xref *Xref, err error) {
+	if value, ok := o.Field("Xref"); ok && value != "" {
+		xref, err = ParseXref(value)
+	}
+	return
+}
+
+// Returns the parsed Xref header of the article, nil if it has none. Only articles returned by the ARTICLE or HEAD
+// commands carry headers.
+func (a *Article) Xref() (xref *Xref, err error) {
+	if value := a.Header.Get("Xref"); value != "" {
+		xref, err = ParseXref(value)
+	}
+	return
+}
+
+// The overviews of the articles of a newsgroup, as scanned with CmdOver or CmdXOver, see DedupCrossPosts.
+type GroupOverviews struct {
+	Group     string
+	Overviews rx.Observable[*ArticleOverview]
+}
+
+// An article found by DedupCrossPosts, with all the places it is filed in.
+type CrossPost struct {
+	Overview *ArticleOverview
+
+	// The newsgroups the article is filed in, taken from its Xref field and always including the group it was first
+	// seen in. When the server sends no valid Xref in overviews, these are the groups the article was seen in.
+	Locations []XrefLocation
+}
+
+// Scans the overviews of several newsgroups in turn and emits each article once, the first time its message-id is
+// seen, cross-posted articles showing up in the groups scanned after being dropped. Articles without a valid Xref are
+// held until all the groups are scanned instead, and emitted last with all the groups they were seen in. The sources
+// are subscribed one after the other, so that each may select its group on a shared connection first, e.g. with
+// rx.Defer:
+//
+//	nntp.GroupOverviews{Group: "misc.test", Overviews: rx.Defer(func(rx.Lifecycle) rx.Observable[*nntp.ArticleOverview] {
+//		if _, err := conn.CmdGroup("misc.test"); err != nil {
+//			return rx.Error[*nntp.ArticleOverview](err)
+//		}
+//		return conn.CmdOver(nntp.WithArticleRange(1, 0))
+//	})}
+//
+// The message-ids seen are remembered until the stream ends.
+func DedupCrossPosts(sources ...GroupOverviews) rx.Observable[*CrossPost] {
+	return rx.Func(func(subscriber rx.Writer[*CrossPost]) (err error) {
+		seen := map[MessageID]*CrossPost{} // nil once emitted
+		var held []*CrossPost
+		for _, source := range sources {
+			writer, reader := rx.Pipe[*ArticleOverview](subscriber, rx.PipeWithDiscardChildError())
+			source.Overviews.Subscribe(writer)
+			for {
+				overview, ok := reader.Read()
+				if !ok {
+					break
+				}
+				id := overview.MessageID.Full()
+				if post, ok := seen[id]; ok {
+					if post != nil {
+						post.Locations = addLocation(post.Locations, source.Group, overview.ArticleNumber)
+					}
+					continue
+				}
+				xref := xrefLocations(overview)
+				post := &CrossPost{overview, addLocation(xref, source.Group, overview.ArticleNumber)}
+				if len(xref) == 0 {
+					// the other groups of the article are only known once they are all scanned
+					seen[id] = post
+					held = append(held, post)
+					continue
+				}
+				seen[id] = nil
+				if !subscriber.Write(post) {
+					return
+				}
+			}
+			if err = reader.Wait(); err != nil {
+				err = fmt.Errorf("[nntp.DedupCrossPosts] %s: %w", source.Group, err)
+				return
+			}
+		}
+		for _, post := range held {
+			if !subscriber.Write(post) {
+				return
+			}
+		}
+		return
+	})
+}
+
+// Returns the locations of an article from its Xref field. An invalid Xref is ignored, dropping cross-posts only needs
+// the message-id.
+func xrefLocations(overview *ArticleOverview) (locations []XrefLocation) {
+	if xref, err := overview.Xref(); err == nil && xref != nil {
+		locations = xref.Locations
+	}
+	return
+}
+
+// Adds the location of an article in a group, unless the group is already listed.
+func addLocation(locations []XrefLocation, group string, articleNumber int64) []XrefLocation {
+	for _, location := range locations {
+		if location.Group == group {
+			return locations
+		}
+	}
+	return append(locations, XrefLocation{group, articleNumber})
+}
@@ -5,6 +5,7 @@ go 1.19
 require (
 	gopkg.in/nntp.v0 v0.0.0-20220926000000-a6b2687471da
 	gopkg.in/rx.v0 v0.0.0-20220421053708-ed88ff42144d
+	gopkg.in/textproto.v0 v0.0.0-20221008000000-eebe43f979c0
 )
 
 require (
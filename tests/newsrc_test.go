@@ -0,0 +1,71 @@
+package nntp_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/nntp.v0"
+	"gopkg.in/nntp.v0/newsrc"
+)
+
+func TestNewsrc(t *testing.T) {
+	const NEWSRC = "options -hide\n" +
+		"comp.lang.go: 1-1520,1523\n" +
+		"alt.binaries.test! 1-9000\n" +
+		"misc.test:\n"
+
+	rc, err := newsrc.Parse(strings.NewReader(NEWSRC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if _, err = rc.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != NEWSRC {
+		t.Errorf("client expects %#v to be written back as is but got %#v", NEWSRC, b.String())
+	}
+	if group := rc.Group("alt.binaries.test"); group == nil || group.Subscribed || group.Read.Len() != 9000 {
+		t.Errorf("client expects alt.binaries.test unsubscribed with 9000 read articles but got %#v", group)
+	}
+
+	stat := &nntp.GroupStat{Count: 30, First: 1500, Last: 1530, Group: "comp.lang.go"}
+	rc.MarkRead("comp.lang.go", 1521, 1522, 1530)
+	if unread := rc.Unread(stat).String(); unread != "1524-1529" {
+		t.Errorf("client expects unread articles %#v but got %#v", "1524-1529", unread)
+	}
+	rc.MarkUnread("comp.lang.go", 1500)
+	rc.CatchUp(&nntp.GroupStat{Count: 2, First: 1, Last: 2, Group: "news.answers"})
+	if unread := rc.Unread(&nntp.GroupStat{Count: 3, First: 1, Last: 3, Group: "news.answers"}).String(); unread != "3" {
+		t.Errorf("client expects unread articles %#v after catching up but got %#v", "3", unread)
+	}
+	rc.Unsubscribe("misc.test")
+
+	path := filepath.Join(t.TempDir(), ".newsrc")
+	if err = rc.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const SAVED = "options -hide\n" +
+		"comp.lang.go: 1-1499,1501-1523,1530\n" +
+		"alt.binaries.test! 1-9000\n" +
+		"misc.test!\n" +
+		"news.answers: 1-2\n"
+	if string(data) != SAVED {
+		t.Errorf("client expects %#v to be saved but got %#v", SAVED, string(data))
+	}
+	if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".newsrc.*")); len(matches) != 0 {
+		t.Errorf("client expects no temporary file left but got %v", matches)
+	}
+
+	if _, err = newsrc.Parse(strings.NewReader("comp.lang.go: 1-x\n")); !errors.Is(err, newsrc.ErrorInvalidLine) {
+		t.Errorf("client expects %v but got %v", newsrc.ErrorInvalidLine, err)
+	}
+}
@@ -1,13 +1,33 @@
 package nntp_test
 
 import (
+	"bufio"
 	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"gopkg.in/nntp.v0"
 	"gopkg.in/rx.v0"
+	"gopkg.in/textproto.v0"
 )
 
 type message struct {
@@ -258,3 +278,2107 @@ func TestOverCommand(t *testing.T) {
 		t.Errorf("client expects %d articles but only got %d", len(ARTICLES), i)
 	}
 }
+
+func TestHdrCommand(t *testing.T) {
+	HEADERS := []*nntp.ArticleHeader{
+		{ArticleNumber: 3000234, Value: "I am just a test article"},
+		{ArticleNumber: 3000235, Value: ""},
+		{ArticleNumber: 3000237, Value: "Re: I am just a test article"},
+	}
+
+	netconn := mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("GROUP misc.test\r\n"),
+		recv("211 1234 3000234 3002322 misc.test\r\n"),
+		send("HDR Subject 3000234-3000237\r\n"),
+		recv("225 Headers follow\r\n"+
+			"3000234 I am just a test article\r\n"+
+			"3000235 \r\n"+
+			"3000237 Re: I am just a test article\r\n"+
+			".\r\n"),
+		send("XHDR Subject <i.am.an.article.you.will.want@example.com>\r\n"),
+		recv("221 Header follows\r\n"+
+			"0 I am just a test article\r\n"+
+			".\r\n"),
+	)
+	conn := nntp.NewConn(netconn)
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.CmdGroup("misc.test"); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, reader := rx.Pipe[*nntp.ArticleHeader](nil)
+	conn.CmdHdr("Subject", nntp.WithArticleRange(3000234, 3000237)).Subscribe(writer)
+	i := 0
+	for {
+		header, ok := reader.Read()
+		if !ok {
+			break
+		}
+		if *header != *HEADERS[i] {
+			t.Errorf("client expects %#v but got %#v", HEADERS[i], header)
+		}
+		i++
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if i != len(HEADERS) {
+		t.Errorf("client expects %d headers but only got %d", len(HEADERS), i)
+	}
+
+	writer, reader = rx.Pipe[*nntp.ArticleHeader](nil)
+	conn.CmdXHdr("Subject", nntp.OverMessageID("i.am.an.article.you.will.want@example.com")).Subscribe(writer)
+	header, ok := reader.Read()
+	if !ok {
+		t.Fatal(reader.Err())
+	}
+	if header.ArticleNumber != 0 || header.Value != HEADERS[0].Value {
+		t.Errorf("client expects %#v but got %#v", HEADERS[0].Value, header)
+	}
+	if _, ok = reader.Read(); ok {
+		t.Errorf("client expects a single header")
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHdrListHeaders(t *testing.T) {
+	conn := nntp.NewConn(mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("LIST HEADERS\r\n"),
+		recv("215 Headers and metadata items supported:\r\n"+
+			"Subject\r\n"+
+			":bytes\r\n"+
+			":\r\n"+
+			".\r\n"),
+		send("HDR Subject <a@example.com>\r\n"),
+		recv("225 Headers follow\r\n0 Hello\r\n.\r\n"),
+		send("HDR :bytes <a@example.com>\r\n"),
+		recv("225 Headers follow\r\n0 1234\r\n.\r\n"),
+		send("LIST HEADERS\r\n"),
+		recv("215 Headers and metadata items supported:\r\n"+
+			"Subject\r\n"+
+			"Bad Field\r\n"+
+			".\r\n"),
+	))
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	anyField, fields, err := conn.CmdListHeaders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !anyField || fmt.Sprint(fields) != "[Subject :bytes]" {
+		t.Errorf("client expects any field along with [Subject :bytes] but got %v, %v", anyField, fields)
+	}
+	// every field listed is accepted by HDR
+	for _, field := range fields {
+		writer, reader := rx.Pipe[*nntp.ArticleHeader](nil)
+		conn.CmdHdr(field, nntp.OverMessageID("a@example.com")).Subscribe(writer)
+		for _, ok := reader.Read(); ok; _, ok = reader.Read() {
+		}
+		if err = reader.Err(); err != nil {
+			t.Errorf("client expects field %#v to be accepted but got %v", field, err)
+		}
+	}
+	// and a field HDR refuses is not listed
+	if _, _, err = conn.CmdListHeaders(); !errors.Is(err, nntp.ErrorParsingResponse) {
+		t.Errorf("client expects %v but got %v", nntp.ErrorParsingResponse, err)
+	}
+	writer, reader := rx.Pipe[*nntp.ArticleHeader](nil)
+	conn.CmdHdr("Bad Field", nntp.OverMessageID("a@example.com")).Subscribe(writer)
+	for _, ok := reader.Read(); ok; _, ok = reader.Read() {
+	}
+	if err = reader.Err(); !errors.Is(err, nntp.ErrorInvalidParams) {
+		t.Errorf("client expects %v but got %v", nntp.ErrorInvalidParams, err)
+	}
+}
+
+func TestHdrLongLine(t *testing.T) {
+	// longer than the 64KB token limit of bufio.Scanner
+	references := strings.TrimSpace(strings.Repeat("<a.long.thread.reference@example.com> ", 5000))
+	conn := nntp.NewConn(mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("GROUP misc.test\r\n"),
+		recv("211 2 1 2 misc.test\r\n"),
+		send("XHDR References 1-2\r\n"),
+		recv("221 References follow\r\n1 "+references+"\r\n2 <a@example.com>\r\n.\r\n"),
+		send("DATE\r\n"),
+		recv("111 20221008123456\r\n"),
+	))
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.CmdGroup("misc.test"); err != nil {
+		t.Fatal(err)
+	}
+	writer, reader := rx.Pipe[*nntp.ArticleHeader](nil)
+	conn.CmdXHdr("References", nntp.WithArticleRange(1, 2)).Subscribe(writer)
+	var headers []*nntp.ArticleHeader
+	for header, ok := reader.Read(); ok; header, ok = reader.Read() {
+		headers = append(headers, header)
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(headers) != 2 || headers[0].Value != references || headers[1].Value != "<a@example.com>" {
+		t.Errorf("client expects 2 headers, the first with %d bytes of references, but got %d", len(references), len(headers))
+	}
+	if _, err := conn.CmdDate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuthinfoSASLCommand(t *testing.T) {
+	netconn := mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("CAPABILITIES\r\n"),
+		recv("101 Capability list:\r\n"+
+			"VERSION 2\r\n"+
+			"READER\r\n"+
+			"SASL CRAM-MD5 PLAIN\r\n"+
+			".\r\n"),
+		send("AUTHINFO SASL CRAM-MD5\r\n"),
+		recv("383 PDE4OTYuNjk3MTcwOTUyQHBvc3RvZmZpY2UucmVzdG9uLm1jaS5uZXQ+\r\n"),
+		send("dGltIGI5MTNhNjAyYzdlZGE3YTQ5NWI0ZTZlNzMzNGQzODkw\r\n"),
+		recv("281 Authentication accepted\r\n"),
+		send("CAPABILITIES\r\n"),
+		recv("101 Capability list:\r\n"+
+			"VERSION 2\r\n"+
+			"READER\r\n"+
+			"SASL PLAIN\r\n"+
+			".\r\n"),
+		send("AUTHINFO SASL PLAIN AHRpbQB0YW5zdGFhZnRhbnN0YWFm\r\n"),
+		recv("481 Authentication failed\r\n"),
+	)
+	conn := nntp.NewConn(netconn)
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.CmdAuthinfoSASL(nntp.SASLScramSHA256("tim", "tanstaaftanstaaf"), nntp.SASLCramMD5("tim", "tanstaaftanstaaf")); err != nil {
+		t.Fatal(err)
+	}
+	// capabilities are read again after authentication
+	err := conn.CmdAuthinfoSASL(nntp.SASLPlain("", "tim", "tanstaaftanstaaf"))
+	if !errors.Is(err, nntp.ResponseCodeAuthenticationFailed) {
+		t.Errorf("client expects authentication failure but got %v", err)
+	}
+}
+
+// A mechanism whose server never proves its identity.
+type unverifiedMechanism struct{}
+
+func (unverifiedMechanism) Name() string                { return "X-MUTUAL" }
+func (unverifiedMechanism) Start() ([]byte, error)      { return []byte("hello"), nil }
+func (unverifiedMechanism) Next([]byte) ([]byte, error) { return nil, nil }
+func (unverifiedMechanism) Complete() bool              { return false }
+
+func TestAuthinfoSASLMutual(t *testing.T) {
+	netconn := mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("CAPABILITIES\r\n"),
+		recv("101 Capability list:\r\n"+
+			"VERSION 2\r\n"+
+			"SASL X-MUTUAL\r\n"+
+			".\r\n"),
+		send("AUTHINFO SASL X-MUTUAL aGVsbG8=\r\n"),
+		// the final server message is skipped
+		recv("281 Authentication accepted\r\n"),
+	)
+	conn := nntp.NewConn(netconn)
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.CmdAuthinfoSASL(unverifiedMechanism{}); !errors.Is(err, nntp.ErrorSASLUnexpectedChallenge) {
+		t.Errorf("client expects %v but got %v", nntp.ErrorSASLUnexpectedChallenge, err)
+	}
+	if conn.State().Authenticated {
+		t.Errorf("client expects the session not to be authenticated")
+	}
+
+	scram := nntp.SASLScramSHA256("tim", "tanstaaftanstaaf")
+	ir, err := scram.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, nonce, _ := strings.Cut(string(ir), ",r=")
+	if _, err = scram.Next([]byte("r=" + nonce + "server,s=QSXCR+Q6sek8bf92,i=2147483647")); !errors.Is(err, nntp.ErrorSASLUnexpectedChallenge) {
+		t.Errorf("client expects the iteration count to be refused but got %v", err)
+	}
+	if scram.(nntp.SASLCompleter).Complete() {
+		t.Errorf("client expects the SCRAM-SHA-256 exchange to be incomplete")
+	}
+}
+
+// The exchange of RFC 7677 section 3, played by the server of TestAuthinfoSASLScram.
+const (
+	scramSalt        = "W22ZaJ0SNY7soEsUEjb6gQ=="
+	scramIterations  = 4096
+	scramServerNonce = "%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0"
+)
+
+func scramHMAC(key []byte, data string) []byte {
+	d := hmac.New(sha256.New, key)
+	d.Write([]byte(data))
+	return d.Sum(nil)
+}
+
+// Returns the client proof and the server signature of a SCRAM-SHA-256 exchange for the password "pencil".
+func scramProof(clientFirstBare, serverFirst, clientFinalWithoutProof string) (proof, signature string) {
+	salt, _ := base64.StdEncoding.DecodeString(scramSalt)
+	u := scramHMAC([]byte("pencil"), string(salt)+"\x00\x00\x00\x01")
+	salted := append([]byte{}, u...)
+	for i := 1; i < scramIterations; i++ {
+		u = scramHMAC([]byte("pencil"), string(u))
+		for j := range salted {
+			salted[j] ^= u[j]
+		}
+	}
+	authMessage := clientFirstBare + "," + serverFirst + "," + clientFinalWithoutProof
+	clientKey := scramHMAC(salted, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	p := scramHMAC(storedKey[:], authMessage)
+	for j := range p {
+		p[j] ^= clientKey[j]
+	}
+	return base64.StdEncoding.EncodeToString(p),
+		base64.StdEncoding.EncodeToString(scramHMAC(scramHMAC(salted, "Server Key"), authMessage))
+}
+
+// Plays the server side of a SCRAM-SHA-256 exchange for user "user", answering with a wrong server signature if
+// tampered.
+func scramServer(t *testing.T, server net.Conn, tampered bool) {
+	r := bufio.NewReader(server)
+	readLine := func() string {
+		line, _ := r.ReadString('\n')
+		return strings.TrimSuffix(line, "\r\n")
+	}
+	decode := func(s string) string {
+		data, _ := base64.StdEncoding.DecodeString(s)
+		return string(data)
+	}
+	server.Write([]byte("200 Welcome to Usenet\r\n"))
+	readLine()
+	server.Write([]byte("101 Capability list:\r\nVERSION 2\r\nSASL SCRAM-SHA-256\r\n.\r\n"))
+	command := strings.Fields(readLine())
+	clientFirstBare := strings.TrimPrefix(decode(command[len(command)-1]), "n,,")
+	_, clientNonce, _ := strings.Cut(clientFirstBare, ",r=")
+	serverFirst := fmt.Sprintf("r=%s%s,s=%s,i=%d", clientNonce, scramServerNonce, scramSalt, scramIterations)
+	server.Write([]byte("383 " + base64.StdEncoding.EncodeToString([]byte(serverFirst)) + "\r\n"))
+	clientFinal := decode(readLine())
+	clientFinalWithoutProof, proof, _ := strings.Cut(clientFinal, ",p=")
+	expected, signature := scramProof(clientFirstBare, serverFirst, clientFinalWithoutProof)
+	if proof != expected {
+		t.Errorf("server expects client proof %#v but got %#v", expected, proof)
+		server.Write([]byte("481 Authentication failed\r\n"))
+		return
+	}
+	if tampered {
+		signature = base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+	}
+	server.Write([]byte("283 " + base64.StdEncoding.EncodeToString([]byte("v="+signature)) + "\r\n"))
+}
+
+func TestAuthinfoSASLScram(t *testing.T) {
+	// the server of the test computes the values of RFC 7677
+	proof, signature := scramProof("n=user,r=rOprNGfwEbeRWgbNEkqO",
+		"r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096",
+		"c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0")
+	if proof != "dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ=" || signature != "6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4=" {
+		t.Fatalf("server computes proof %#v and signature %#v unlike RFC 7677", proof, signature)
+	}
+
+	for _, tampered := range []bool{false, true} {
+		client, server := net.Pipe()
+		go scramServer(t, server, tampered)
+		conn := nntp.NewConn(client)
+		if err := conn.ReadWelcome(); err != nil {
+			t.Fatal(err)
+		}
+		err := conn.CmdAuthinfoSASL(nntp.SASLScramSHA256("user", "pencil"))
+		if !tampered {
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !conn.State().Authenticated {
+				t.Errorf("client expects the session to be authenticated")
+			}
+			continue
+		}
+		if !errors.Is(err, nntp.ErrorSASLUnexpectedChallenge) {
+			t.Errorf("client expects the server signature to be refused but got %v", err)
+		}
+		// the server considers the session authenticated
+		if conn.State().Authenticated || !errors.Is(conn.Err(), nntp.ErrorConnUnusable) {
+			t.Errorf("client expects the connection to be unusable but got %v", conn.Err())
+		}
+	}
+}
+
+// A mechanism failing on the first challenge.
+type failingMechanism struct{}
+
+func (failingMechanism) Name() string                { return "X-FAILING" }
+func (failingMechanism) Start() ([]byte, error)      { return nil, nil }
+func (failingMechanism) Next([]byte) ([]byte, error) { return nil, io.ErrUnexpectedEOF }
+
+func TestAuthinfoSASLFailure(t *testing.T) {
+	netconn := mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("CAPABILITIES\r\n"),
+		recv("101 Capability list:\r\n"+
+			"VERSION 2\r\n"+
+			"SASL OAUTHBEARER X-FAILING\r\n"+
+			".\r\n"),
+		// the error status of RFC 7628 is answered with a dummy response
+		send("AUTHINFO SASL OAUTHBEARER "+base64.StdEncoding.EncodeToString([]byte("n,a=user,\x01auth=Bearer token\x01\x01"))+"\r\n"),
+		recv("383 "+base64.StdEncoding.EncodeToString([]byte(`{"status":"invalid_token"}`))+"\r\n"),
+		send("AQ==\r\n"),
+		recv("481 Authentication failed\r\n"),
+		// the exchange is cancelled when the mechanism fails
+		send("AUTHINFO SASL X-FAILING\r\n"),
+		recv("383 =\r\n"),
+		send("*\r\n"),
+		recv("481 Authentication cancelled\r\n"),
+		send("DATE\r\n"),
+		recv("111 20221008123456\r\n"),
+	)
+	conn := nntp.NewConn(netconn)
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.CmdAuthinfoSASL(nntp.SASLOAuthBearer("user", "token")); !errors.Is(err, nntp.ResponseCodeAuthenticationFailed) {
+		t.Errorf("client expects authentication failure but got %v", err)
+	}
+	if err := conn.CmdAuthinfoSASL(failingMechanism{}); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("client expects the error of the mechanism but got %v", err)
+	}
+	if err := conn.Err(); err != nil {
+		t.Errorf("client expects the connection to remain usable but got %v", err)
+	}
+	if _, err := conn.CmdDate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func deflate(data ...string) string {
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	for _, d := range data {
+		w.Write([]byte(d))
+		w.Flush()
+	}
+	return buf.String()
+}
+
+func TestCompress(t *testing.T) {
+	netconn := mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("CAPABILITIES\r\n"),
+		recv("101 Capability list:\r\n"+
+			"VERSION 2\r\n"+
+			"READER\r\n"+
+			"COMPRESS DEFLATE\r\n"+
+			".\r\n"),
+		send("COMPRESS DEFLATE\r\n"),
+		recv("206 Compression active\r\n"),
+		send(deflate("DATE\r\n")),
+		recv(deflate("111 20221008123456\r\n")),
+	)
+	conn := nntp.NewConn(netconn)
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Compress(); err != nil {
+		t.Fatal(err)
+	}
+	if !conn.IsCompressed() {
+		t.Errorf("client expects compression to be active")
+	}
+	date, err := conn.CmdDate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2022, 10, 8, 12, 34, 56, 0, time.UTC); !date.Equal(expected) {
+		t.Errorf("client expects %v but got %v", expected, date)
+	}
+	if err = conn.Compress(); err == nil {
+		t.Errorf("client expects compression to be refused once active")
+	}
+}
+
+func TestCompressConcurrent(t *testing.T) {
+	client, server := net.Pipe()
+	compressing, accept := make(chan struct{}), make(chan struct{})
+	go func() {
+		r := bufio.NewReader(server)
+		expect := func(r *bufio.Reader, line string) {
+			if got, err := r.ReadString('\n'); err != nil || got != line {
+				panic(fmt.Errorf("server expects %#v but got %#v: %v", line, got, err))
+			}
+		}
+		server.Write([]byte("200 Welcome to Usenet\r\n"))
+		expect(r, "CAPABILITIES\r\n")
+		server.Write([]byte("101 Capability list:\r\nVERSION 2\r\nREADER\r\nCOMPRESS DEFLATE\r\n.\r\n"))
+		expect(r, "COMPRESS DEFLATE\r\n")
+		close(compressing)
+		<-accept
+		server.Write([]byte("206 Compression active\r\n"))
+		w, _ := flate.NewWriter(server, flate.DefaultCompression)
+		// a second COMPRESS would show up here
+		expect(bufio.NewReader(flate.NewReader(r)), "DATE\r\n")
+		w.Write([]byte("111 20221008123456\r\n"))
+		w.Flush()
+	}()
+	conn := nntp.NewConn(client)
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Capabilities(); err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error, 2)
+	go func() { errs <- conn.Compress() }()
+	<-compressing
+	go func() { errs <- conn.Compress() }()
+	// lets the second call wait for the first one
+	time.Sleep(50 * time.Millisecond)
+	close(accept)
+	first, second := <-errs, <-errs
+	if first != nil {
+		first, second = second, first
+	}
+	if first != nil || !errors.Is(second, nntp.ErrorInvalidParams) {
+		t.Errorf("client expects one call to succeed and the other to be refused but got %v and %v", first, second)
+	}
+	if _, err := conn.CmdDate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompressUnsupported(t *testing.T) {
+	netconn := mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("CAPABILITIES\r\n"),
+		recv("101 Capability list:\r\n"+
+			"VERSION 2\r\n"+
+			"READER\r\n"+
+			".\r\n"),
+	)
+	conn := nntp.NewConn(netconn)
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Compress(); !errors.Is(err, nntp.ErrorCapabilityMissing) {
+		t.Errorf("client expects missing capability error but got %v", err)
+	}
+}
+
+func TestStreamFeed(t *testing.T) {
+	ARTICLES := []*nntp.Article{
+		{MessageID: "<i.am.an.article.you.will.want@example.com>",
+			Header: textproto.MIMEHeader{"Subject": {"I am just a test article"}},
+			Body:   strings.NewReader("This is just a test article.\n")},
+		{MessageID: "<i.am.an.article.you.have@example.com>",
+			Header: textproto.MIMEHeader{"Subject": {"I am just a test article"}},
+			Body:   strings.NewReader("This is just a test article.\n")},
+	}
+	RESULTS := []nntp.FeedResult{
+		{MessageID: ARTICLES[0].MessageID, Check: nntp.ResponseCodeCheckSend, TakeThis: nntp.ResponseCodeTakeThisSuccess},
+		{MessageID: ARTICLES[1].MessageID, Check: nntp.ResponseCodeCheckUnwanted},
+	}
+
+	netconn := mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("MODE STREAM\r\n"),
+		recv("203 Streaming permitted\r\n"),
+		send("CHECK <i.am.an.article.you.will.want@example.com>\r\n"),
+		recv("238 <i.am.an.article.you.will.want@example.com>\r\n"),
+		send("TAKETHIS <i.am.an.article.you.will.want@example.com>\r\n"+
+			"Subject: I am just a test article\r\n"+
+			"Message-Id: <i.am.an.article.you.will.want@example.com>\r\n"+
+			"\r\n"+
+			"This is just a test article.\r\n"+
+			".\r\n"),
+		recv("239 <i.am.an.article.you.will.want@example.com>\r\n"),
+		send("CHECK <i.am.an.article.you.have@example.com>\r\n"),
+		recv("438 <i.am.an.article.you.have@example.com>\r\n"),
+	)
+	conn := nntp.NewConn(netconn)
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.CmdModeStream(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, reader := rx.Pipe[*nntp.FeedResult](nil)
+	conn.CmdStreamFeed(rx.List(ARTICLES), nntp.WithFeedWindow(1)).Subscribe(writer)
+	i := 0
+	for {
+		result, ok := reader.Read()
+		if !ok {
+			break
+		}
+		if *result != RESULTS[i] {
+			t.Errorf("client expects %#v but got %#v", RESULTS[i], result)
+		}
+		i++
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if i != len(RESULTS) {
+		t.Errorf("client expects %d results but only got %d", len(RESULTS), i)
+	}
+}
+
+func TestStreamFeedOrder(t *testing.T) {
+	ARTICLES := []*nntp.Article{
+		{MessageID: "<a@example.com>", Header: textproto.MIMEHeader{}, Body: strings.NewReader("A\n")},
+		{MessageID: "<b@example.com>", Header: textproto.MIMEHeader{}, Body: strings.NewReader("B\n")},
+		{MessageID: "<c@example.com>", Header: textproto.MIMEHeader{}, Body: strings.NewReader("C\n")},
+	}
+	netconn := mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("CHECK <a@example.com>\r\nCHECK <b@example.com>\r\nCHECK <c@example.com>\r\n"),
+		recv("238 <a@example.com>\r\n438 <b@example.com>\r\n"),
+		// the TAKETHIS response of the first article comes after the CHECK responses of the others
+		send("TAKETHIS <a@example.com>\r\nMessage-Id: <a@example.com>\r\n\r\nA\r\n.\r\n"),
+		recv("438 <c@example.com>\r\n239 <a@example.com>\r\n"),
+	)
+	conn := nntp.NewConn(netconn)
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, reader := rx.Pipe[*nntp.FeedResult](nil)
+	conn.CmdStreamFeed(rx.List(ARTICLES), nntp.WithFeedWindow(3)).Subscribe(writer)
+	var results []string
+	for result, ok := reader.Read(); ok; result, ok = reader.Read() {
+		results = append(results, fmt.Sprintf("%s:%d/%d", result.MessageID, result.Check, result.TakeThis))
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if EXPECTED := "<a@example.com>:238/239,<b@example.com>:438/0,<c@example.com>:438/0"; strings.Join(results, ",") != EXPECTED {
+		t.Errorf("client expects results in source order %v but got %v", EXPECTED, results)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	netconn := mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("CAPABILITIES\r\n"),
+		recv("101 Capability list:\r\n"+
+			"VERSION 2\r\n"+
+			"MODE-READER\r\n"+
+			"IHAVE\r\n"+
+			"IMPLEMENTATION INN 2.6.4\r\n"+
+			".\r\n"),
+		send("MODE READER\r\n"),
+		recv("200 Posting allowed\r\n"),
+		send("CAPABILITIES\r\n"),
+		recv("101 Capability list:\r\n"+
+			"VERSION 2\r\n"+
+			"READER\r\n"+
+			"POST\r\n"+
+			"HDR\r\n"+
+			"OVER MSGID\r\n"+
+			"LIST ACTIVE NEWSGROUPS OVERVIEW.FMT HEADERS\r\n"+
+			"SASL PLAIN SCRAM-SHA-256\r\n"+
+			"STARTTLS\r\n"+
+			".\r\n"),
+	)
+	conn := nntp.NewConn(netconn)
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	caps, err := conn.Capabilities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !caps.ModeReader || caps.Reader || !caps.IHave || caps.Implementation != "INN 2.6.4" {
+		t.Errorf("client parsed unexpected capabilities %#v", caps)
+	}
+	if cached, _ := conn.Capabilities(); cached != caps {
+		t.Errorf("client expects capabilities to be cached")
+	}
+	if _, err = conn.CmdModeReader(); err != nil {
+		t.Fatal(err)
+	}
+	if caps, err = conn.Capabilities(); err != nil {
+		t.Fatal(err)
+	}
+	if !caps.Reader || !caps.Post || !caps.Hdr || !caps.Over || !caps.OverMessageID || !caps.StartTLS || caps.IHave {
+		t.Errorf("client parsed unexpected capabilities %#v", caps)
+	}
+	if !caps.Has("over", "msgid") || !caps.Has("LIST", "ACTIVE", "HEADERS") || caps.Has("LIST", "MOTD") || caps.Has("COMPRESS") {
+		t.Errorf("client parsed unexpected capabilities %#v", caps)
+	}
+	if mechanisms, ok := caps.Args("SASL"); !ok || len(mechanisms) != 2 || mechanisms[1] != "SCRAM-SHA-256" {
+		t.Errorf("client parsed unexpected SASL mechanisms %#v", mechanisms)
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	client, server := net.Pipe()
+	go func() {
+		server.Write([]byte("200 Welcome to Usenet\r\n"))
+		// swallow commands without ever responding
+		io.Copy(io.Discard, server)
+	}()
+	conn := nntp.NewConn(client)
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := conn.WithContext(ctx).CmdDate(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("client expects deadline exceeded error but got %v", err)
+	}
+	if err := conn.Err(); !errors.Is(err, nntp.ErrorConnUnusable) {
+		t.Errorf("client expects connection to be unusable but got %v", err)
+	}
+	if _, err := conn.CmdDate(); !errors.Is(err, nntp.ErrorConnUnusable) {
+		t.Errorf("client expects unusable connection error but got %v", err)
+	}
+}
+
+func TestBodyCancellation(t *testing.T) {
+	client, server := net.Pipe()
+	go func() {
+		server.Write([]byte("200 Welcome to Usenet\r\n"))
+		r := bufio.NewReader(server)
+		r.ReadString('\n')
+		server.Write([]byte("222 0 <a@example.com>\r\nHello\r\n"))
+		// stall in the middle of the body
+		io.Copy(io.Discard, r)
+	}()
+	conn := nntp.NewConn(client)
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	article, err := conn.WithContext(ctx).CmdBody(nntp.ArticleMessageID("a@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the context stays bound across the reads of the body
+	buf := make([]byte, 1)
+	for i := 0; i < len("Hello\n"); i++ {
+		if _, err = io.ReadFull(article.Body, buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err = article.Body.Read(buf); !errors.Is(err, context.Canceled) {
+		t.Errorf("client expects canceled error but got %v", err)
+	}
+	if err = conn.Err(); !errors.Is(err, nntp.ErrorConnUnusable) {
+		t.Errorf("client expects connection to be unusable but got %v", err)
+	}
+}
+
+// Serves one script per accepted connection, in order.
+func mockListener(t *testing.T, scripts ...[]*message) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for _, script := range scripts {
+			netconn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(netconn net.Conn, script []*message) {
+				defer netconn.Close()
+				client := mockServer(script...)
+				go io.Copy(client, netconn)
+				io.Copy(netconn, client)
+			}(netconn, script)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// Returns a self-signed certificate for 127.0.0.1, along with the roots trusting it.
+func testCertificate(t *testing.T) (certificate tls.Certificate, roots *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots = x509.NewCertPool()
+	roots.AddCert(parsed)
+	certificate = tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	return
+}
+
+// Plays the script on a server connection, which unlike mockServer may then be upgraded to TLS.
+func playScript(t *testing.T, conn io.ReadWriter, messages ...*message) bool {
+	for _, message := range messages {
+		if message.send {
+			buf := make([]byte, len(message.data))
+			if _, err := io.ReadFull(conn, buf); err != nil || !bytes.Equal(buf, message.data) {
+				t.Errorf("server expects %#v but got %#v, %v", string(message.data), string(buf), err)
+				return false
+			}
+		} else if _, err := conn.Write(message.data); err != nil {
+			t.Errorf("server failed to send %#v: %v", string(message.data), err)
+			return false
+		}
+	}
+	return true
+}
+
+func TestStartTLS(t *testing.T) {
+	certificate, roots := testCertificate(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		netconn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer netconn.Close()
+		if !playScript(t, netconn,
+			recv("200 Welcome to Usenet\r\n"),
+			send("CAPABILITIES\r\n"),
+			recv("101 Capability list:\r\nVERSION 2\r\nREADER\r\nSTARTTLS\r\n.\r\n"),
+			send("STARTTLS\r\n"),
+			recv("382 Continue with TLS negotiation\r\n"),
+		) {
+			return
+		}
+		tlsconn := tls.Server(netconn, &tls.Config{Certificates: []tls.Certificate{certificate}})
+		playScript(t, tlsconn,
+			send("AUTHINFO user testuser\r\n"),
+			recv("381 PASS required\r\n"),
+			send("AUTHINFO pass testpass\r\n"),
+			recv("281 Welcome to Usenet\r\n"),
+		)
+	}()
+
+	ctx := context.Background()
+	dialer := &nntp.Dialer{Config: &tls.Config{RootCAs: roots}, RequireStartTLS: true}
+	conn, err := dialer.Dial(ctx, "tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if !conn.IsTLS() {
+		t.Errorf("client expects the connection to be upgraded to TLS")
+	}
+	if err = conn.CmdAuthinfo("testuser", "testpass"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStartTLSRequired(t *testing.T) {
+	addr := mockListener(t, []*message{
+		recv("200 Welcome to Usenet\r\n"),
+		send("CAPABILITIES\r\n"),
+		recv("101 Capability list:\r\nVERSION 2\r\nREADER\r\nAUTHINFO USER\r\n.\r\n"),
+	})
+	dialer := &nntp.Dialer{RequireStartTLS: true}
+	conn, err := dialer.Dial(context.Background(), "tcp", addr)
+	if !errors.Is(err, nntp.ErrorTLSRequired) {
+		t.Errorf("client expects %v without STARTTLS but got %v", nntp.ErrorTLSRequired, err)
+	}
+	// credentials are never sent in the clear
+	if err = conn.CmdAuthinfo("testuser", "testpass"); !errors.Is(err, nntp.ErrorTLSRequired) {
+		t.Errorf("client expects %v but got %v", nntp.ErrorTLSRequired, err)
+	}
+}
+
+func TestStartTLSInjection(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		defer server.Close()
+		playScript(t, server,
+			recv("200 Welcome to Usenet\r\n"),
+			send("STARTTLS\r\n"),
+			// a response injected in the plaintext stream would be read as coming from the TLS server
+			recv("382 Continue with TLS negotiation\r\n281 Authentication accepted\r\n"),
+		)
+	}()
+	conn := nntp.NewConn(client)
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.StartTLS(&tls.Config{}); !errors.Is(err, nntp.ErrorParsingResponse) {
+		t.Errorf("client expects %v but got %v", nntp.ErrorParsingResponse, err)
+	}
+	if conn.IsTLS() {
+		t.Errorf("client expects the connection not to be upgraded")
+	}
+}
+
+func TestPool(t *testing.T) {
+	addr := mockListener(t, []*message{
+		recv("200 Welcome to Usenet\r\n"),
+		send("AUTHINFO user testuser\r\n"),
+		recv("381 PASS required\r\n"),
+		send("AUTHINFO pass testpass\r\n"),
+		recv("281 Welcome to Usenet\r\n"),
+		send("MODE READER\r\n"),
+		recv("200 Posting allowed\r\n"),
+		send("DATE\r\n"),
+		recv("111 20221008123456\r\n"),
+		send("DATE\r\n"),
+		recv("111 20221008123457\r\n"),
+	})
+	pool := &nntp.Pool{Addr: addr, Username: "testuser", Password: "testpass", ModeReader: true, MaxConns: 1}
+	defer pool.Close()
+
+	ctx := context.Background()
+	conn, err := pool.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = conn.CmdDate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the only connection is checked out
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err = pool.Get(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("client expects to wait for a connection but got %v", err)
+	}
+
+	pool.Put(conn)
+	if pool.Idle() != 1 {
+		t.Errorf("client expects 1 idle connection but got %d", pool.Idle())
+	}
+	if conn, err = pool.Get(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = conn.CmdDate(); err != nil {
+		t.Fatal(err)
+	}
+	pool.Put(conn)
+}
+
+func TestPoolPendingBody(t *testing.T) {
+	addr := mockListener(t, []*message{
+		recv("200 Welcome to Usenet\r\n"),
+		send("BODY <a@example.com>\r\n"),
+		recv("222 0 <a@example.com>\r\n"),
+		recv("Hello\r\n.\r\n"),
+	}, []*message{
+		recv("200 Welcome to Usenet\r\n"),
+		send("DATE\r\n"),
+		recv("111 20221008123456\r\n"),
+	})
+	pool := &nntp.Pool{Addr: addr, MaxConns: 1}
+	defer pool.Close()
+
+	ctx := context.Background()
+	conn, err := pool.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = conn.CmdBody(nntp.ArticleMessageID("a@example.com")); err != nil {
+		t.Fatal(err)
+	}
+	// the body is left unread
+	pool.Put(conn)
+	if pool.Idle() != 0 {
+		t.Errorf("client expects the connection with a pending body to be closed but got %d idle", pool.Idle())
+	}
+	timeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if conn, err = pool.Get(timeout); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = conn.WithContext(timeout).CmdDate(); err != nil {
+		t.Fatal(err)
+	}
+	pool.Put(conn)
+}
+
+func TestClientFailover(t *testing.T) {
+	primary := &nntp.Provider{Name: "primary", Pool: &nntp.Pool{Addr: mockListener(t, []*message{
+		recv("200 Welcome to Usenet\r\n"),
+		send("BODY <missing@example.com>\r\n"),
+		recv("430 No such article\r\n"),
+	})}}
+	block := &nntp.Provider{Name: "block", Priority: 1, Pool: &nntp.Pool{Addr: mockListener(t, []*message{
+		recv("200 Welcome to Usenet\r\n"),
+		send("BODY <missing@example.com>\r\n"),
+		recv("222 0 <missing@example.com>\r\n"),
+		recv("Hello\r\n.\r\n"),
+	})}}
+	client := nntp.NewClient(block, primary)
+	defer client.Close()
+
+	article, provider, err := client.CmdBody(context.Background(), nntp.ArticleMessageID("missing@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider != block {
+		t.Errorf("client expects the article from the block provider but got %s", provider.Name)
+	}
+	if body, _ := io.ReadAll(article.Body); string(body) != "Hello\n" {
+		t.Errorf("client expects body %#v but got %#v", "Hello\n", string(body))
+	}
+
+	if _, _, err = client.CmdBody(context.Background(), nntp.ArticleNumber(1)); !errors.Is(err, nntp.ErrorInvalidParams) {
+		t.Errorf("client expects article numbers to be refused but got %v", err)
+	}
+}
+
+func TestStreamHead(t *testing.T) {
+	netconn := mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("GROUP misc.test\r\n"),
+		recv("211 2 3000 3001 misc.test\r\n"),
+		send("HEAD <a@example.com>\r\n"),
+		recv("221 0 <a@example.com>\r\n"),
+		recv("Subject: first\r\n.\r\n"),
+		send("HEAD <missing@example.com>\r\n"),
+		recv("430 No such article\r\n"),
+		send("HEAD 3000\r\n"),
+		recv("221 3000 <b@example.com>\r\n"),
+		recv("Subject: second\r\n.\r\n"),
+		send("STAT 3001\r\n"),
+		recv("223 3001 <c@example.com>\r\n"),
+	)
+	conn := nntp.NewConn(netconn)
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.CmdGroup("misc.test"); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := rx.List([]nntp.ArticleOption{
+		nntp.ArticleMessageID("a@example.com"),
+		nntp.ArticleMessageID("missing@example.com"),
+		nntp.ArticleNumber(3000),
+	})
+	writer, reader := rx.Pipe[*nntp.ArticleResult](nil)
+	conn.CmdStreamHead(requests, nntp.WithStreamWindow(2)).Subscribe(writer)
+	var results []string
+	for {
+		result, ok := reader.Read()
+		if !ok {
+			break
+		}
+		if result.Err != nil {
+			results = append(results, fmt.Sprintf("%s:%d", result.MessageID, result.Err.Code))
+		} else {
+			results = append(results, result.Article.Header.Get("Subject"))
+		}
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(results, ",") != "first,<missing@example.com>:430,second" {
+		t.Errorf("client expects results in request order but got %v", results)
+	}
+
+	writer, reader = rx.Pipe[*nntp.ArticleResult](nil)
+	conn.CmdStreamStat(rx.Of(nntp.ArticleNumber(3001))).Subscribe(writer)
+	if result, ok := reader.Read(); !ok || result.Article == nil || result.Article.MessageID != "<c@example.com>" {
+		t.Errorf("client expects article <c@example.com> but got %#v", result)
+	}
+	for _, ok := reader.Read(); ok; _, ok = reader.Read() {
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStreamNoGroup(t *testing.T) {
+	conn := nntp.NewConn(mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("STAT <a@example.com>\r\n"),
+		recv("223 0 <a@example.com>\r\n"),
+		// the request by article number is never sent
+		send("DATE\r\n"),
+		recv("111 20221008123456\r\n"),
+	))
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	writer, reader := rx.Pipe[*nntp.ArticleResult](nil)
+	requests := rx.List([]nntp.ArticleOption{nntp.ArticleMessageID("a@example.com"), nntp.ArticleNumber(3000)})
+	conn.CmdStreamStat(requests).Subscribe(writer)
+	n := 0
+	for _, ok := reader.Read(); ok; _, ok = reader.Read() {
+		n++
+	}
+	if err := reader.Err(); n != 1 || !errors.Is(err, nntp.ErrorNoGroupSelected) {
+		t.Errorf("client expects one result and no group selected error but got %d, %v", n, err)
+	}
+	if _, err := conn.CmdDate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHeadLongHeader(t *testing.T) {
+	// longer than the 4KB read buffer of the connection
+	references := strings.TrimSpace(strings.Repeat("<a.long.thread.reference@example.com> ", 200))
+	head := "Subject: long thread\r\nReferences: " + references + "\r\n.\r\n"
+	conn := nntp.NewConn(mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("HEAD <a@example.com>\r\n"),
+		recv("221 0 <a@example.com>\r\n"+head),
+		send("HEAD <a@example.com>\r\n"),
+		recv("221 0 <a@example.com>\r\n"+head),
+		send("DATE\r\n"),
+		recv("111 20221008123456\r\n"),
+	))
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	article, err := conn.CmdHead(nntp.ArticleMessageID("a@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if article.Header.Get("References") != references {
+		t.Errorf("client expects %d bytes of references but got %d", len(references), len(article.Header.Get("References")))
+	}
+
+	writer, reader := rx.Pipe[*nntp.ArticleResult](nil)
+	conn.CmdStreamHead(rx.Of(nntp.ArticleMessageID("a@example.com"))).Subscribe(writer)
+	for result, ok := reader.Read(); ok; result, ok = reader.Read() {
+		if result.Err != nil || result.Article.Header.Get("References") != references {
+			t.Errorf("client expects %d bytes of references but got %#v", len(references), result)
+		}
+	}
+	if err = reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = conn.CmdDate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStreamBody(t *testing.T) {
+	netconn := mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("BODY <a@example.com>\r\n"),
+		recv("222 0 <a@example.com>\r\n"),
+		recv("skipped\r\n.\r\n"),
+		send("BODY <b@example.com>\r\n"),
+		recv("222 0 <b@example.com>\r\n"),
+		recv("..leading dot\r\n.\r\n"),
+		send("BODY <c@example.com>\r\n"),
+		recv("222 0 <c@example.com>\r\n"),
+		recv("buffered\r\n.\r\n"),
+	)
+	conn := nntp.NewConn(netconn)
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := rx.List([]nntp.ArticleOption{nntp.ArticleMessageID("a@example.com"), nntp.ArticleMessageID("b@example.com")})
+	writer, reader := rx.Pipe[*nntp.ArticleResult](nil)
+	conn.CmdStreamBody(requests, nntp.WithStreamWindow(1)).Subscribe(writer)
+	result, ok := reader.Read()
+	if !ok || result.Article == nil {
+		t.Fatalf("client expects the first article but got %#v", result)
+	}
+	// the body is drained without being read
+	if err := result.Article.Body.(io.Closer).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if result, ok = reader.Read(); !ok || result.Article == nil {
+		t.Fatalf("client expects the second article but got %#v", result)
+	}
+	if body, err := io.ReadAll(result.Article.Body); err != nil || string(body) != ".leading dot\n" {
+		t.Errorf("client expects body %#v but got %#v, %v", ".leading dot\n", string(body), err)
+	}
+	for _, ok := reader.Read(); ok; _, ok = reader.Read() {
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, reader = rx.Pipe[*nntp.ArticleResult](nil)
+	conn.CmdStreamBody(rx.Of(nntp.ArticleMessageID("c@example.com")), nntp.WithBufferedBodies()).Subscribe(writer)
+	var results []*nntp.ArticleResult
+	for result, ok := reader.Read(); ok; result, ok = reader.Read() {
+		results = append(results, result)
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Article == nil {
+		t.Fatalf("client expects one article but got %#v", results)
+	}
+	if body, _ := io.ReadAll(results[0].Article.Body); string(body) != "buffered\n" {
+		t.Errorf("client expects body %#v but got %#v", "buffered\n", string(body))
+	}
+}
+
+func TestConcurrentCommands(t *testing.T) {
+	const N = 8
+	script := []*message{
+		recv("200 Welcome to Usenet\r\n"),
+		send("BODY <a@example.com>\r\n"),
+		recv("222 0 <a@example.com>\r\n"),
+		recv("never read\r\n.\r\n"),
+		send("STAT <a@example.com>\r\n"),
+		recv("223 0 <a@example.com>\r\n"),
+	}
+	for i := 0; i < N; i++ {
+		script = append(script, send("DATE\r\n"), recv("111 20221008123456\r\n"))
+	}
+	conn := nntp.NewConn(mockServer(script...))
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the body left unread is drained by the next command of the same view
+	if _, err := conn.CmdBody(nntp.ArticleMessageID("a@example.com")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.CmdStat(nntp.ArticleMessageID("a@example.com")); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, N)
+	for i := 0; i < N; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := conn.WithContext(context.Background()).CmdDate(); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if err := conn.Err(); err != nil {
+		t.Errorf("client expects the connection to remain usable but got %v", err)
+	}
+}
+
+func TestPendingBodyOtherView(t *testing.T) {
+	conn := nntp.NewConn(mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("BODY <a@example.com>\r\n"),
+		recv("222 0 <a@example.com>\r\n"),
+		recv("Hello\r\nWorld\r\n.\r\n"),
+		send("STAT <a@example.com>\r\n"),
+		recv("223 0 <a@example.com>\r\n"),
+	))
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	article, err := conn.WithContext(ctx).CmdBody(nntp.ArticleMessageID("a@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the body left unread by another view is read in memory instead of blocking the command
+	if _, err = conn.WithContext(ctx).CmdStat(nntp.ArticleMessageID("a@example.com")); err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(article.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "Hello\nWorld\n" {
+		t.Errorf("client expects body %#v but got %#v", "Hello\nWorld\n", string(body))
+	}
+	if err = conn.Err(); err != nil {
+		t.Errorf("client expects the connection to remain usable but got %v", err)
+	}
+}
+
+func TestSessionState(t *testing.T) {
+	conn := nntp.NewConn(mockServer(
+		recv("201 Welcome to Usenet, no posting\r\n"),
+		send("MODE READER\r\n"),
+		recv("200 Reader mode, posting permitted\r\n"),
+		send("GROUP misc.test\r\n"),
+		recv("211 3 3000234 3000237 misc.test\r\n"),
+		send("STAT 3000237\r\n"),
+		recv("223 3000237 <a@example.com>\r\n"),
+		send("STAT <b@example.com>\r\n"),
+		recv("223 0 <b@example.com>\r\n"),
+	))
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if state := conn.State(); state.PostingAllowed || state.Mode != nntp.SessionModeUnknown || state.Group != nil {
+		t.Errorf("client expects a fresh session but got %#v", state)
+	}
+
+	// commands referring to an article number fail fast without sending anything
+	if _, err := conn.CmdStat(nntp.ArticleNumber(3000237)); !errors.Is(err, nntp.ErrorNoGroupSelected) {
+		t.Errorf("client expects %v but got %v", nntp.ErrorNoGroupSelected, err)
+	}
+
+	if _, err := conn.CmdModeReader(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.CmdGroup("misc.test"); err != nil {
+		t.Fatal(err)
+	}
+	state := conn.State()
+	if !state.PostingAllowed || state.Mode != nntp.SessionModeReader {
+		t.Errorf("client expects reader mode with posting allowed but got %#v", state)
+	}
+	if state.Group == nil || state.Group.Group != "misc.test" || state.ArticleNumber != 3000234 {
+		t.Errorf("client expects misc.test selected at its first article but got %#v", state)
+	}
+
+	if _, err := conn.CmdStat(nntp.ArticleNumber(3000237)); err != nil {
+		t.Fatal(err)
+	}
+	// selecting an article by message-id leaves the current article number alone
+	if _, err := conn.CmdStat(nntp.ArticleMessageID("b@example.com")); err != nil {
+		t.Fatal(err)
+	}
+	if state := conn.State(); state.ArticleNumber != 3000237 || state.TLS || state.Compressed || state.Authenticated {
+		t.Errorf("client expects the current article to be 3000237 but got %#v", state)
+	}
+}
+
+func TestCursor(t *testing.T) {
+	conn := nntp.NewConn(mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("GROUP misc.test\r\n"),
+		recv("211 2 3000234 3000237 misc.test\r\n"),
+		send("STAT\r\n"),
+		recv("223 3000234 <a@example.com>\r\n"),
+		send("HEAD 3000234\r\n"),
+		recv("221 3000234 <a@example.com>\r\n"+
+			"Subject: first\r\n"+
+			".\r\n"),
+		send("NEXT\r\n"),
+		recv("223 3000237 <b@example.com>\r\n"),
+		send("BODY 3000237\r\n"),
+		recv("222 3000237 <b@example.com>\r\n"+
+			"second\r\n"+
+			".\r\n"),
+		send("NEXT\r\n"),
+		recv("421 No next article in this group\r\n"),
+		send("LAST\r\n"),
+		recv("223 3000234 <a@example.com>\r\n"),
+	))
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.CmdGroup("misc.test"); err != nil {
+		t.Fatal(err)
+	}
+
+	cursor := conn.NewCursor()
+	if !cursor.Next() {
+		t.Fatal(cursor.Err())
+	}
+	// the header is fetched once and cached
+	for i := 0; i < 2; i++ {
+		header, err := cursor.Head()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if subject := header.Get("Subject"); subject != "first" {
+			t.Errorf("client expects subject %#v but got %#v", "first", subject)
+		}
+	}
+
+	if !cursor.Next() {
+		t.Fatal(cursor.Err())
+	}
+	if article := cursor.Article(); article.ArticleNumber != 3000237 || article.MessageID != "<b@example.com>" {
+		t.Errorf("client expects article 3000237 but got %#v", article)
+	}
+	body, err := cursor.Body()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := io.ReadAll(body); err != nil || string(b) != "second\n" {
+		t.Errorf("client expects body %#v but got %#v, %v", "second\n", string(b), err)
+	}
+
+	if cursor.Next() || cursor.Err() != nil {
+		t.Errorf("client expects the end of the group but got %v", cursor.Err())
+	}
+	if article := cursor.Article(); article.ArticleNumber != 3000237 {
+		t.Errorf("client expects the cursor to stay at 3000237 but got %#v", article)
+	}
+
+	if !cursor.Prev() {
+		t.Fatal(cursor.Err())
+	}
+	if article := cursor.Article(); article.ArticleNumber != 3000234 || article.Header != nil {
+		t.Errorf("client expects article 3000234 with no header yet but got %#v", article)
+	}
+	if state := conn.State(); state.ArticleNumber != 3000234 {
+		t.Errorf("client expects the current article to be 3000234 but got %d", state.ArticleNumber)
+	}
+}
+
+func TestArticleNumbers(t *testing.T) {
+	conn := nntp.NewConn(mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("LISTGROUP alt.binaries.test\r\n"),
+		recv("211 2 9223372036854775806 9223372036854775807 alt.binaries.test\r\n"+
+			"9223372036854775806\r\n"+
+			"9223372036854775807\r\n"+
+			".\r\n"),
+		send("STAT 9223372036854775807\r\n"),
+		recv("223 9223372036854775808 <a@example.com>\r\n"),
+	))
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	group, articles, err := conn.CmdListGroup(nntp.GroupName("alt.binaries.test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.Last != math.MaxInt64 || articles.Len() != 2 || !articles.Contains(math.MaxInt64) {
+		t.Errorf("client expects article numbers up to %d but got %#v, %v", int64(math.MaxInt64), group, articles)
+	}
+	// numbers beyond 2^63-1 are rejected rather than wrapped around
+	if _, err = conn.CmdStat(nntp.ArticleNumber(math.MaxInt64)); !errors.Is(err, nntp.ErrorParsingResponse) {
+		t.Errorf("client expects %v but got %v", nntp.ErrorParsingResponse, err)
+	}
+	// a zero bound stands for the first or the last article
+	for r, expected := range map[nntp.Range]string{{Last: 10}: "1-10", {}: "1-", {First: 5}: "5-", {First: 1, Last: math.MaxInt64}: "1-9223372036854775807"} {
+		if r.String() != expected {
+			t.Errorf("client expects range %#v to be %#v but got %#v", r, expected, r.String())
+		}
+	}
+}
+
+func TestArticleSet(t *testing.T) {
+	set, err := nntp.ParseArticleSet("9-120000, 7,1-3,2-5,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := set.String(); s != "1-5,7,9-120000" {
+		t.Errorf("client expects %#v but got %#v", "1-5,7,9-120000", s)
+	}
+	if set.Len() != 120000-9+1+6 || !set.Contains(7) || set.Contains(8) || set.Contains(120001) {
+		t.Errorf("client expects 1-5,7,9-120000 but got %d numbers", set.Len())
+	}
+	for _, invalid := range []string{"5-1", "a", "-3", "1-", "18446744073709551616"} {
+		if _, err := nntp.ParseArticleSet(invalid); !errors.Is(err, nntp.ErrorInvalidParams) {
+			t.Errorf("client expects %#v to be rejected but got %v", invalid, err)
+		}
+	}
+
+	other, _ := nntp.ParseArticleSet("3-8,100-200,9223372036854775807")
+	for _, tc := range []struct{ name, got, expected string }{
+		{"union", set.Union(other).String(), "1-120000,9223372036854775807"},
+		{"intersection", set.Intersect(other).String(), "3-5,7,100-200"},
+		{"difference", set.Difference(other).String(), "1-2,9-99,201-120000"},
+		{"reverse difference", other.Difference(set).String(), "6,8,9223372036854775807"},
+		{"empty union", (*nntp.ArticleSet)(nil).Union(other).String(), other.String()},
+	} {
+		if tc.got != tc.expected {
+			t.Errorf("client expects %s %#v but got %#v", tc.name, tc.expected, tc.got)
+		}
+	}
+
+	merged, _ := nntp.ParseArticleSet("1-2,5,7-8,12,20")
+	merged.AddRange(3, 10)
+	merged.AddRange(15, 16)
+	if merged.String() != "1-10,12,15-16,20" {
+		t.Errorf("client expects %#v but got %#v", "1-10,12,15-16,20", merged.String())
+	}
+
+	// LISTGROUP adds numbers in ascending order, mostly next to the last one
+	ascending := &nntp.ArticleSet{}
+	next := int64(1)
+	if allocs := testing.AllocsPerRun(1000, func() {
+		ascending.Add(next)
+		next++
+	}); allocs != 0 || ascending.String() != fmt.Sprintf("1-%d", next-1) {
+		t.Errorf("client expects ascending adds to extend the last range without allocating but got %v allocations, %s", allocs, ascending)
+	}
+
+	var visited []int64
+	other.Each(func(articleNumber int64) bool {
+		visited = append(visited, articleNumber)
+		return len(visited) < 8
+	})
+	if fmt.Sprint(visited) != "[3 4 5 6 7 8 100 101]" {
+		t.Errorf("client expects to stop after 8 numbers but got %v", visited)
+	}
+}
+
+func TestOverArticleSet(t *testing.T) {
+	conn := nntp.NewConn(mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("GROUP misc.test\r\n"),
+		recv("211 4 1 9 misc.test\r\n"),
+		send("XHDR Subject 1-2\r\n"),
+		recv("221 Header follows\r\n"+
+			"1 first\r\n"+
+			"2 second\r\n"+
+			".\r\n"),
+		send("XHDR Subject 5-5\r\n"),
+		recv("420 No article(s) selected\r\n"),
+		send("XHDR Subject 9-9\r\n"),
+		recv("221 Header follows\r\n"+
+			"9 ninth\r\n"+
+			".\r\n"),
+	))
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.CmdGroup("misc.test"); err != nil {
+		t.Fatal(err)
+	}
+	set, _ := nntp.ParseArticleSet("1-2,5,9")
+	writer, reader := rx.Pipe[*nntp.ArticleHeader](nil)
+	conn.CmdXHdr("Subject", nntp.WithArticleSet(set)).Subscribe(writer)
+	var values []string
+	for {
+		header, ok := reader.Read()
+		if !ok {
+			break
+		}
+		values = append(values, fmt.Sprintf("%d %s", header.ArticleNumber, header.Value))
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(values) != "[1 first 2 second 9 ninth]" {
+		t.Errorf("client expects the headers of articles 1, 2 and 9 but got %v", values)
+	}
+}
+
+func TestListVariants(t *testing.T) {
+	conn := nntp.NewConn(mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("LIST ACTIVE.TIMES misc.*\r\n"),
+		recv("215 information follows\r\n"+
+			"misc.test 930445408 <creatme@isc.org>\r\n"+
+			".\r\n"),
+		send("LIST DISTRIB.PATS\r\n"),
+		recv("215 information follows\r\n"+
+			"10:local.*:local\r\n"+
+			"5:*:world\r\n"+
+			".\r\n"),
+		send("LIST DISTRIBUTIONS\r\n"),
+		recv("215 information follows\r\n"+
+			"fr Local to France.\r\n"+
+			".\r\n"),
+		send("LIST MODERATORS\r\n"),
+		recv("215 information follows\r\n"+
+			"*:%s@moderators.example.com\r\n"+
+			".\r\n"),
+		send("LIST MOTD\r\n"),
+		recv("215 information follows\r\n"+
+			"Maintenance tonight.\r\n"+
+			".\r\n"),
+		send("LIST SUBSCRIPTIONS\r\n"),
+		recv("215 information follows\r\n"+
+			"news.announce.newusers\r\n"+
+			".\r\n"),
+		send("LIST COUNTS\r\n"),
+		recv("215 information follows\r\n"+
+			"misc.test 3002322 3000234 1234 y\r\n"+
+			".\r\n"),
+	))
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+
+	times, err := conn.CmdListActiveTimes("misc.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(times) != 1 || times[0].Group != "misc.test" || times[0].Created.Unix() != 930445408 || times[0].Creator != "<creatme@isc.org>" {
+		t.Errorf("client expects the creation of misc.test but got %#v", times)
+	}
+	patterns, err := conn.CmdListDistribPats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patterns) != 2 || patterns[0] != (nntp.DistributionPatternListItem{Weight: 10, Wildmat: "local.*", Distribution: "local"}) {
+		t.Errorf("client expects two distribution patterns but got %#v", patterns)
+	}
+	distributions, err := conn.CmdListDistributions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(distributions) != 1 || distributions[0] != (nntp.DistributionListItem{Distribution: "fr", Description: "Local to France."}) {
+		t.Errorf("client expects the fr distribution but got %#v", distributions)
+	}
+	moderators, err := conn.CmdListModerators()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moderators) != 1 || moderators[0] != (nntp.ModeratorListItem{Pattern: "*", Address: "%s@moderators.example.com"}) {
+		t.Errorf("client expects a catch-all moderator but got %#v", moderators)
+	}
+	motd, err := conn.CmdListMotd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := io.ReadAll(motd); err != nil || string(b) != "Maintenance tonight.\n" {
+		t.Errorf("client expects the message of the day but got %#v, %v", string(b), err)
+	}
+	subscriptions, err := conn.CmdListSubscriptions("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subscriptions) != 1 || subscriptions[0] != "news.announce.newusers" {
+		t.Errorf("client expects news.announce.newusers but got %#v", subscriptions)
+	}
+	counts, err := conn.CmdListCounts("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 1 || counts[0] != (nntp.GroupCountListItem{Group: "misc.test", Last: 3002322, First: 3000234, Count: 1234, Permission: nntp.GroupPostingPermitted}) {
+		t.Errorf("client expects the count of misc.test but got %#v", counts)
+	}
+}
+
+func TestStreamListActive(t *testing.T) {
+	conn := nntp.NewConn(mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("LIST ACTIVE alt.*\r\n"),
+		recv("215 list of newsgroups follows\r\n"+
+			"alt.binaries.test 3002322 3000234 y\r\n"+
+			"alt.test 12 1 m\r\n"+
+			"alt.test.moderated 9 1 m\r\n"+
+			".\r\n"),
+		send("DATE\r\n"),
+		recv("111 20221008123456\r\n"),
+	))
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, reader := rx.Pipe[*nntp.GroupListItem](nil)
+	rx.Take(conn.CmdStreamListActive("alt.*"), 1).Subscribe(writer)
+	var groups []string
+	for {
+		group, ok := reader.Read()
+		if !ok {
+			break
+		}
+		groups = append(groups, group.Group)
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0] != "alt.binaries.test" {
+		t.Errorf("client expects the first group only but got %v", groups)
+	}
+
+	// the rest of the list is dropped before the next response is read
+	if _, err := conn.CmdDate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOverviewFormat(t *testing.T) {
+	const LINE = "5\tHello\tsomeone@example.com\tSat, 08 Oct 2022 12:34:56 GMT\t<a@example.com>\t\t1234\t\t" +
+		"Xref: e misc.test:5\t\t42\r\n"
+	conn := nntp.NewConn(mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("GROUP misc.test\r\n"),
+		recv("211 1 5 5 misc.test\r\n"),
+		send("OVER\r\n"),
+		recv("224 Overview information follows\r\n"+LINE+".\r\n"),
+		send("LIST OVERVIEW.FMT\r\n"),
+		recv("215 Order of fields in overview database.\r\n"+
+			"Subject:\r\nFrom:\r\nDate:\r\nMessage-ID:\r\nReferences:\r\n:bytes\r\n:lines\r\n"+
+			"Xref:full\r\nDistribution:full\r\n:lines\r\n"+
+			".\r\n"),
+		send("OVER\r\n"),
+		recv("224 Overview information follows\r\n"+LINE+".\r\n"),
+	))
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.CmdGroup("misc.test"); err != nil {
+		t.Fatal(err)
+	}
+	over := func() *nntp.ArticleOverview {
+		writer, reader := rx.Pipe[*nntp.ArticleOverview](nil)
+		conn.CmdOver().Subscribe(writer)
+		article, ok := reader.Read()
+		if !ok {
+			t.Fatal(reader.Err())
+		}
+		return article
+	}
+
+	// without the format only the fields carrying a header name prefix can be told apart
+	article := over()
+	if xref, ok := article.Field("xref"); !ok || xref != "e misc.test:5" {
+		t.Errorf("client expects Xref %#v but got %#v", "e misc.test:5", xref)
+	}
+	if _, ok := article.Field(":lines"); !ok || len(article.Extra) != 1 {
+		t.Errorf("client expects only the Xref extra field but got %#v", article.Extra)
+	}
+
+	if _, err := conn.CmdListOverviewFmt(); err != nil {
+		t.Fatal(err)
+	}
+	article = over()
+	for _, field := range []struct{ name, value string }{
+		{"Subject", "Hello"},
+		{"Xref", "e misc.test:5"},
+		{"Distribution", ""},
+		{":bytes", "1234"},
+		{":lines", "42"},
+	} {
+		if value, ok := article.Field(field.name); !ok || value != field.value {
+			t.Errorf("client expects %s %#v but got %#v, %v", field.name, field.value, value, ok)
+		}
+	}
+	if article.ExtraFields[0] != "Xref: e misc.test:5" {
+		t.Errorf("client expects the raw extra fields to be kept but got %#v", article.ExtraFields)
+	}
+}
+
+func TestDedupCrossPosts(t *testing.T) {
+	xref, err := nntp.ParseXref("Xref: news.example.com misc.test:5 alt.test:12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if xref.Server != "news.example.com" || len(xref.Locations) != 2 || xref.Locations[1] != (nntp.XrefLocation{Group: "alt.test", ArticleNumber: 12}) {
+		t.Errorf("client expects two locations on news.example.com but got %#v", xref)
+	}
+	for _, invalid := range []string{"news.example.com", "news.example.com misc.test", "news.example.com misc.test:x"} {
+		if _, err := nntp.ParseXref(invalid); !errors.Is(err, nntp.ErrorParsingResponse) {
+			t.Errorf("client expects %#v to be rejected but got %v", invalid, err)
+		}
+	}
+
+	overview := func(number int64, id string, extra ...string) *nntp.ArticleOverview {
+		return &nntp.ArticleOverview{ArticleNumber: number, MessageID: nntp.MessageID(id), ExtraFields: extra,
+			Extra: []nntp.OverviewField{{
+				OverviewFieldFormat: nntp.OverviewFieldFormat{Name: "Xref", Type: nntp.FullHeaderOverviewField},
+				Value:               strings.Join(extra, ""),
+			}}}
+	}
+	writer, reader := rx.Pipe[*nntp.CrossPost](nil)
+	nntp.DedupCrossPosts(
+		nntp.GroupOverviews{Group: "misc.test", Overviews: rx.List([]*nntp.ArticleOverview{
+			overview(5, "<a@example.com>", "e misc.test:5 alt.test:12"),
+			overview(6, "<b@example.com>"),
+		})},
+		nntp.GroupOverviews{Group: "alt.test", Overviews: rx.List([]*nntp.ArticleOverview{
+			overview(12, "a@example.com", "e misc.test:5 alt.test:12"),
+			overview(13, "<c@example.com>", "e alt.test:13 alt.misc:7"),
+			// without Xref, the groups the article is seen in are merged
+			overview(14, "<b@example.com>"),
+		})},
+	).Subscribe(writer)
+	var posts []string
+	for {
+		post, ok := reader.Read()
+		if !ok {
+			break
+		}
+		posts = append(posts, fmt.Sprintf("%s %v", post.Overview.MessageID, post.Locations))
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	const EXPECTED = "[<a@example.com> [{misc.test 5} {alt.test 12}] <c@example.com> [{alt.test 13} {alt.misc 7}] " +
+		"<b@example.com> [{misc.test 6} {alt.test 14}]]"
+	if fmt.Sprint(posts) != EXPECTED {
+		t.Errorf("client expects %s but got %v", EXPECTED, posts)
+	}
+}
+
+func TestOverviewFallback(t *testing.T) {
+	const LINE = "1\tHello\tsomeone@example.com\tSat, 08 Oct 2022 12:34:56 GMT\t<a@example.com>\t\t1234\t12\r\n"
+	collect := func(overviews rx.Observable[*nntp.ArticleOverview]) (n int, err error) {
+		writer, reader := rx.Pipe[*nntp.ArticleOverview](nil)
+		overviews.Subscribe(writer)
+		for _, ok := reader.Read(); ok; _, ok = reader.Read() {
+			n++
+		}
+		return n, reader.Err()
+	}
+
+	// a legacy server knowing neither CAPABILITIES nor XOVER
+	conn := nntp.NewConn(mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("GROUP misc.test\r\n"),
+		recv("211 1 1 1 misc.test\r\n"),
+		send("CAPABILITIES\r\n"),
+		recv("500 What?\r\n"),
+		send("XOVER 1-1\r\n"),
+		recv("500 What?\r\n"),
+		send("OVER 1-1\r\n"),
+		recv("224 Overview information follows\r\n"+LINE+".\r\n"),
+		send("OVER 1-1\r\n"),
+		recv("224 Overview information follows\r\n"+LINE+".\r\n"),
+		send("XHDR Subject 1-1\r\n"),
+		recv("221 Header follows\r\n1 Hello\r\n.\r\n"),
+	))
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.CmdGroup("misc.test"); err != nil {
+		t.Fatal(err)
+	}
+	// the unknown commands are only tried once
+	for i := 0; i < 2; i++ {
+		if n, err := collect(conn.Overview(nntp.WithArticleRange(1, 1))); err != nil || n != 1 {
+			t.Fatalf("client expects a single overview but got %d, %v", n, err)
+		}
+	}
+	if _, err := collect(conn.Overview(nntp.OverMessageID("a@example.com"))); !errors.Is(err, nntp.ErrorCapabilityMissing) {
+		t.Errorf("client expects %v without OVER MSGID but got %v", nntp.ErrorCapabilityMissing, err)
+	}
+	writer, reader := rx.Pipe[*nntp.ArticleHeader](nil)
+	conn.Headers("Subject", nntp.WithArticleRange(1, 1)).Subscribe(writer)
+	if header, ok := reader.Read(); !ok || header.Value != "Hello" {
+		t.Errorf("client expects the subject of article 1 but got %#v, %v", header, reader.Err())
+	}
+
+	// a server advertising OVER MSGID and HDR
+	conn = nntp.NewConn(mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("CAPABILITIES\r\n"),
+		recv("101 Capability list:\r\nVERSION 2\r\nREADER\r\nOVER MSGID\r\nHDR\r\n.\r\n"),
+		send("OVER <a@example.com>\r\n"),
+		recv("224 Overview information follows\r\n"+LINE+".\r\n"),
+		send("HDR Subject <a@example.com>\r\n"),
+		recv("225 Headers follow\r\n0 Hello\r\n.\r\n"),
+	))
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := collect(conn.Overview(nntp.OverMessageID("a@example.com"))); err != nil || n != 1 {
+		t.Fatalf("client expects a single overview but got %d, %v", n, err)
+	}
+	writer, reader = rx.Pipe[*nntp.ArticleHeader](nil)
+	conn.Headers("Subject", nntp.OverMessageID("a@example.com")).Subscribe(writer)
+	if header, ok := reader.Read(); !ok || header.Value != "Hello" {
+		t.Errorf("client expects the subject of <a@example.com> but got %#v, %v", header, reader.Err())
+	}
+}
+
+func TestOverviewDecoder(t *testing.T) {
+	// References lines of long threads exceed the 64KB token limit of bufio.Scanner
+	references := strings.Repeat("<a.long.thread.reference@example.com> ", 5000)
+	line := func(number int) string {
+		return fmt.Sprintf("%d\tRe: Hello\tsomeone@example.com\tSat, 08 Oct 2022 12:34:56 GMT\t<%d@example.com>\t%s\t1234\t12\tXref: e misc.test:%d\r\n",
+			number, number, references, number)
+	}
+	conn := nntp.NewConn(mockServer(
+		recv("200 Welcome to Usenet\r\n"),
+		send("GROUP misc.test\r\n"),
+		recv("211 3 1 3 misc.test\r\n"),
+		send("XOVER 1-3\r\n"),
+		recv("224 Overview information follows\r\n"+line(1)+line(2)+line(3)+".\r\n"),
+		send("XOVER 1-3\r\n"),
+		recv("224 Overview information follows\r\n"+line(1)+line(2)+line(3)+".\r\n"),
+		send("DATE\r\n"),
+		recv("111 20221008123456\r\n"),
+	))
+	if err := conn.ReadWelcome(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.CmdGroup("misc.test"); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, reader := rx.Pipe[*nntp.ArticleOverview](nil)
+	conn.CmdXOver(nntp.WithArticleRange(1, 3)).Subscribe(writer)
+	n := 0
+	for article, ok := reader.Read(); ok; article, ok = reader.Read() {
+		if article.References != references {
+			t.Errorf("client expects %d bytes of references but got %d", len(references), len(article.References))
+		}
+		n++
+	}
+	if err := reader.Err(); err != nil || n != 3 {
+		t.Fatalf("client expects 3 overviews but got %d, %v", n, err)
+	}
+
+	decoder, err := conn.CmdXOverDecoder(nntp.WithArticleRange(1, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	article := nntp.GetArticleOverview()
+	if !decoder.Next() {
+		t.Fatal(decoder.Err())
+	}
+	if err = decoder.Decode(article); err != nil {
+		t.Fatal(err)
+	}
+	if xref, _ := article.Field("Xref"); article.ArticleNumber != 1 || article.Lines != 12 || xref != "e misc.test:1" {
+		t.Errorf("client expects the overview of article 1 but got %#v", article)
+	}
+	if string(decoder.Field(4)) != "<1@example.com>" || decoder.NumFields() != 9 {
+		t.Errorf("client expects 9 fields with message-id <1@example.com> but got %d, %#v", decoder.NumFields(), string(decoder.Field(4)))
+	}
+	nntp.PutArticleOverview(article)
+	// the rest of the overviews is dropped before the next response is read
+	if err = decoder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if decoder.Next() {
+		t.Errorf("client expects no more lines after Close")
+	}
+	if _, err = conn.CmdDate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// decoding into a reused overview does not allocate once the decoder has warmed up
+	decoder = nntp.NewOverviewDecoder(bytes.NewReader(overviewBody(100)), nil)
+	article = nntp.GetArticleOverview()
+	defer nntp.PutArticleOverview(article)
+	allocs := testing.AllocsPerRun(50, func() {
+		if !decoder.Next() || decoder.Decode(article) != nil {
+			t.Fatal("client expects an overview line")
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("client expects decoding not to allocate but got %v allocations per line", allocs)
+	}
+}
+
+// A typical overview response body, as read from a DotReader.
+func overviewBody(lines int) []byte {
+	var b bytes.Buffer
+	for i := 1; i <= lines; i++ {
+		fmt.Fprintf(&b, "%d\t[%03d/112] \"BFMlDDFLa1SxxromF4cORw.part%03d.rar\" yEnc (1/2947)\tKKsT8FSZc6uWH@ngPost.com\t"+
+			"Sun, 25 Sep 2022 03:03:36 GMT\t<%032x@ngPost>\t\t739081\t5691\tXref: e alt.binaries.test:%d\n", 6084661434+i, i%112, i%112, i, 6084661434+i)
+	}
+	return b.Bytes()
+}
+
+// The overview parsing used before OverviewDecoder: bufio.Scanner, strings.Split and an overview per line.
+func BenchmarkOverviewScannerSplit(b *testing.B) {
+	body := overviewBody(1000)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			fields := strings.Split(scanner.Text(), "\t")
+			article := &nntp.ArticleOverview{}
+			article.ArticleNumber, _ = strconv.ParseInt(fields[0], 10, 64)
+			article.Subject, article.From, article.Date, article.MessageID, article.References = fields[1], fields[2], nntp.Timestamp(fields[3]), nntp.MessageID(fields[4]), fields[5]
+			article.Bytes, _ = strconv.ParseUint(fields[6], 10, 64)
+			article.Lines, _ = strconv.ParseUint(fields[7], 10, 64)
+			article.ExtraFields = fields[8:]
+		}
+	}
+}
+
+func BenchmarkOverviewDecoderDecode(b *testing.B) {
+	body := overviewBody(1000)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	article := nntp.GetArticleOverview()
+	defer nntp.PutArticleOverview(article)
+	for i := 0; i < b.N; i++ {
+		decoder := nntp.NewOverviewDecoder(bytes.NewReader(body), nil)
+		for decoder.Next() {
+			if err := decoder.Decode(article); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkOverviewDecoderFields(b *testing.B) {
+	body := overviewBody(1000)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		decoder := nntp.NewOverviewDecoder(bytes.NewReader(body), nil)
+		for decoder.Next() {
+			if _, err := decoder.ArticleNumber(); err != nil {
+				b.Fatal(err)
+			}
+			_ = decoder.Field(4)
+		}
+	}
+}
+
+func TestPoolOverview(t *testing.T) {
+	addr := mockListener(t, []*message{
+		recv("200 Welcome to Usenet\r\n"),
+		send("GROUP misc.test\r\n"),
+		recv("211 4 1 4 misc.test\r\n"),
+		send("CAPABILITIES\r\n"),
+		recv("500 Unknown command\r\n"),
+		send("XOVER 1-2\r\n"),
+		recv("224 Overview information follows\r\n" + overviewLine(1) + overviewLine(2) + ".\r\n"),
+		send("XOVER 3-4\r\n"),
+		recv("403 Internal fault\r\n"),
+		// the connection is still in sync and the only one, so the chunk is tried again on it
+		send("XOVER 3-4\r\n"),
+		recv("224 Overview information follows\r\n" + overviewLine(3) + overviewLine(4) + ".\r\n"),
+		send("GROUP misc.missing\r\n"),
+		recv("411 No such group\r\n"),
+	})
+	pool := &nntp.Pool{Addr: addr}
+	defer pool.Close()
+
+	ctx := context.Background()
+	writer, reader := rx.Pipe[*nntp.ArticleOverview](nil)
+	pool.GroupOverview(ctx, &nntp.GroupStat{Count: 4, First: 1, Last: 4, Group: "misc.test"},
+		nntp.WithParallelConns(1), nntp.WithChunkSize(2)).Subscribe(writer)
+	var numbers []int64
+	for overview, ok := reader.Read(); ok; overview, ok = reader.Read() {
+		numbers = append(numbers, overview.ArticleNumber)
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(numbers) != "[1 2 3 4]" {
+		t.Errorf("client expects articles [1 2 3 4] but got %v", numbers)
+	}
+
+	// a missing group is not retried
+	writer, reader = rx.Pipe[*nntp.ArticleOverview](nil)
+	pool.Overview(ctx, "misc.missing", nntp.Range{First: 1, Last: 2}).Subscribe(writer)
+	for _, ok := reader.Read(); ok; _, ok = reader.Read() {
+	}
+	if err := reader.Err(); !errors.Is(err, nntp.ResponseCodeNoSuchGroup) {
+		t.Errorf("client expects %v but got %v", nntp.ResponseCodeNoSuchGroup, err)
+	}
+}
+
+func overviewLine(number int64) string {
+	return fmt.Sprintf("%d\tSubject %d\tsomeone@example.com\tSat, 08 Oct 2022 12:34:56 GMT\t<%d@example.com>\t\t1234\t12\r\n",
+		number, number, number)
+}
+
+func TestPoolOverviewParallel(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	var (
+		mu         sync.Mutex
+		accepted   int
+		failedOn   = -1 // the connection answering the first XOVER 5-6 with 403
+		retriedOn  = -1
+		laterChunk = make(chan struct{})
+	)
+	// answers each request as it comes, whichever connection the client sends it on
+	serve := func(id int, netconn net.Conn) {
+		defer netconn.Close()
+		conn := textproto.NewConn(netconn)
+		conn.PrintfLine("200 Welcome to Usenet")
+		for {
+			line, err := conn.ReadLine()
+			if err != nil {
+				return
+			}
+			var first, last int64
+			switch {
+			case line == "GROUP misc.test":
+				conn.PrintfLine("211 6 1 6 misc.test")
+			case line == "CAPABILITIES":
+				conn.PrintfLine("500 Unknown command")
+			case line == "XOVER 1-2":
+				// the first chunk is answered after the second one
+				<-laterChunk
+				fallthrough
+			default:
+				if _, err = fmt.Sscanf(line, "XOVER %d-%d", &first, &last); err != nil {
+					conn.PrintfLine("500 Unknown command")
+					continue
+				}
+				if first == 5 {
+					mu.Lock()
+					if failedOn < 0 {
+						failedOn = id
+						mu.Unlock()
+						conn.PrintfLine("403 Internal fault")
+						continue
+					}
+					retriedOn = id
+					mu.Unlock()
+				}
+				conn.PrintfLine("224 Overview information follows")
+				w := conn.DotWriter()
+				for n := first; n <= last; n++ {
+					io.WriteString(w, overviewLine(n))
+				}
+				w.Close()
+				if first == 3 {
+					close(laterChunk)
+				}
+			}
+		}
+	}
+	go func() {
+		for {
+			netconn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			accepted++
+			id := accepted
+			mu.Unlock()
+			go serve(id, netconn)
+		}
+	}()
+	pool := &nntp.Pool{Addr: listener.Addr().String()}
+	defer pool.Close()
+
+	writer, reader := rx.Pipe[*nntp.ArticleOverview](nil)
+	pool.Overview(context.Background(), "misc.test", nntp.Range{First: 1, Last: 6},
+		nntp.WithParallelConns(2), nntp.WithChunkSize(2)).Subscribe(writer)
+	var numbers []int64
+	for overview, ok := reader.Read(); ok; overview, ok = reader.Read() {
+		numbers = append(numbers, overview.ArticleNumber)
+	}
+	if err = reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(numbers) != "[1 2 3 4 5 6]" {
+		t.Errorf("client expects articles in order [1 2 3 4 5 6] but got %v", numbers)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if failedOn < 0 || retriedOn == failedOn {
+		t.Errorf("client expects the failed chunk to be tried again on another connection but got %d then %d", failedOn, retriedOn)
+	}
+	if accepted != 2 {
+		t.Errorf("client expects the connection answering 403 to be kept but got %d connections", accepted)
+	}
+}